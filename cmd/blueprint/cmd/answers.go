@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var answersCmd = &cobra.Command{
+	Use:   "answers",
+	Short: "Work with blueprint answers files",
+	Long:  "Work with the answers files used to generate blueprints non-interactively",
+}
+
+var answersInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate an answers file skeleton for a blueprint",
+	Long: `Generate a commented answers file with one key for every parameter of the blueprint, including all included
+blueprints, that can be asked. Every key is annotated with its type, options, default value and the condition
+under which it is asked. Static default values are filled in, all other answers are left empty.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		context := buildContext()
+		DoAnswersInit(getBlueprintContext(context, answersLocalRepoPath), answersBlueprintPath, answersOutputFile)
+	},
+}
+
+var answersLocalRepoPath string
+var answersBlueprintPath string
+var answersOutputFile string
+
+// DoAnswersInit prints the answers file skeleton of a blueprint or writes it to the given file
+func DoAnswersInit(blueprintContext *blueprint.BlueprintContext, blueprintPath string, outputFile string) {
+	skeleton, err := blueprintContext.GenerateAnswersSkeleton(blueprintPath)
+	if err != nil {
+		util.Fatal("Error while generating answers file: %s\n", err)
+	}
+
+	if outputFile == "" {
+		util.Print("%s", skeleton)
+		return
+	}
+	if util.PathExists(outputFile, false) {
+		util.Fatal("File %s already exists, remove it or choose another file\n", outputFile)
+	}
+	if err := ioutil.WriteFile(outputFile, []byte(skeleton), 0640); err != nil {
+		util.Fatal("Error while writing answers file: %s\n", err)
+	}
+	util.Info("Answers file written to %s\n", outputFile)
+}
+
+func init() {
+	rootCmd.AddCommand(answersCmd)
+	answersCmd.AddCommand(answersInitCmd)
+
+	answersInitFlags := answersInitCmd.Flags()
+	answersInitFlags.StringVarP(&answersBlueprintPath, "blueprint", "b", "", "Blueprint path to generate the answers file for, relative to the active repository")
+	answersInitFlags.StringVarP(&answersLocalRepoPath, "local-repo", "l", "", "Local repository directory to use (bypasses active repository)")
+	answersInitFlags.StringVarP(&answersOutputFile, "file", "f", "", "File to write the answers file to instead of the standard output")
+	answersInitCmd.MarkFlagRequired("blueprint")
+}
@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
 	"github.com/xebialabs/blueprint-cli/pkg/models"
 	"github.com/xebialabs/blueprint-cli/pkg/util"
 	"github.com/xebialabs/blueprint-cli/pkg/xl"
+	"gopkg.in/AlecAivazis/survey.v1"
 )
 
 var blueprintCmd = &cobra.Command{
@@ -14,39 +20,185 @@ var blueprintCmd = &cobra.Command{
 	Short: "(default) Create a Blueprint",
 	Long:  "Process a Blueprint from the active repository",
 	Run: func(cmd *cobra.Command, args []string) {
-		context, err := xl.BuildContext(viper.GetViper(), CliVersion)
-		if err != nil {
-			util.Fatal("Error while reading configuration: %s\n", err)
+		validateOutputFormat(blueprintOutputFormat, outputFormatText, outputFormatJSON)
+		startJSONOutput(blueprintOutputFormat)
+		params.AnswersMap, params.AnswerFiles = getAnswersFromFlags(answerSets, answerSetFiles)
+		if len(defaultSets) > 0 {
+			params.OverrideDefaults = parseKeyValuePairs("default", defaultSets)
 		}
-		if util.IsVerbose {
-			context.PrintConfiguration()
+		if envAnswers || cmd.Flags().Changed("env-answers-prefix") {
+			if envAnswersPrefix == "" {
+				util.Fatal("The prefix of the answer environment variables cannot be empty\n")
+			}
+			params.AnswersEnvPrefix = envAnswersPrefix
 		}
-
+		context := buildContext()
 		DoBlueprint(context)
 	},
 }
 
 var localRepoPath string
 var params = blueprint.BlueprintParams{}
+var outputDir string
+var dryRun bool
+var showDiff bool
+var answerSets []string
+var answerSetFiles []string
+var defaultSets []string
+var envAnswers bool
+var envAnswersPrefix string
+var saveAnswersFile string
+var blueprintOutputFormat string
 
 // DoBlueprint creates blueprint templates
 func DoBlueprint(context *xl.Context) {
 	// if in dev local repo mode, recreate context
-	var err error
-	blueprintContext := context.BlueprintContext
-	if localRepoPath != "" {
-		blueprintContext, err = blueprint.ConstructLocalBlueprintContext(localRepoPath)
+	blueprintContext := getBlueprintContext(context, localRepoPath)
+
+	generatedBlueprint := &blueprint.GeneratedBlueprint{
+		BaseDir:   outputDir,
+		OutputDir: models.BlueprintOutputDir,
+		DryRun:    dryRun || showDiff,
+	}
+	var surveyOpts []survey.AskOpt
+	if blueprintOutputFormat == outputFormatJSON {
+		// keep stdout for the JSON document, questions are asked on stderr
+		surveyOpts = append(surveyOpts, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr))
+	}
+	// the replaced files are restored when saving the answers fails or the command is interrupted until then
+	params.KeepBackups = true
+	stopInterruptHandler := rollbackOnInterrupt(generatedBlueprint)
+	preparedData, blueprintDoc, err := blueprint.InstantiateBlueprint(params, blueprintContext, generatedBlueprint, nil, surveyOpts...)
+	if err != nil {
+		generatedBlueprint.Cleanup() // Cleanup the partially generated blueprint
+		fatalOutput(blueprintOutputFormat, err, "Error while creating Blueprint: %s\n", err)
+	}
+	if saveAnswersFile != "" {
+		if err := saveAnswers(generatedBlueprint.Manifest.Blueprint, blueprintDoc, preparedData, generatedBlueprint.DryRun); err != nil {
+			generatedBlueprint.Cleanup()
+			fatalOutput(blueprintOutputFormat, err, "Error while saving answers: %s\n", err)
+		}
+	}
+	stopInterruptHandler()
+	// the project files can no longer be restored once the command has succeeded
+	if err := generatedBlueprint.Finish(); err != nil {
+		fatalOutput(blueprintOutputFormat, err, "Error while removing the backup of the project files: %s\n", err)
+	}
+	if blueprintOutputFormat == outputFormatJSON {
+		printJSON(blueprint.NewBlueprintResult(generatedBlueprint, blueprintDoc, preparedData))
+		return
+	}
+	if generatedBlueprint.DryRun {
+		printDryRunReport(generatedBlueprint, showDiff)
+	}
+}
+
+// saveAnswers writes the answers of the generated blueprint to the --save-answers file, in dry-run mode the file is
+// left untouched like the project files
+func saveAnswers(templatePath string, blueprintDoc *blueprint.BlueprintConfig, preparedData *blueprint.PreparedData, dryRun bool) error {
+	answers, err := blueprint.FormatAnswers(templatePath, blueprintDoc, preparedData)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		util.Info("Dry run, answers would be saved to %s\n", saveAnswersFile)
+		return nil
+	}
+	if err := ioutil.WriteFile(saveAnswersFile, []byte(answers), 0640); err != nil {
+		return err
+	}
+	util.Info("Answers saved to %s\n", saveAnswersFile)
+	return nil
+}
+
+// rollbackOnInterrupt restores the files of the project when the command is interrupted before it has succeeded, the
+// returned function stops handling interrupts
+func rollbackOnInterrupt(generatedBlueprint *blueprint.GeneratedBlueprint) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan bool)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			if err := generatedBlueprint.Cleanup(); err != nil {
+				util.Fatal("Interrupted, error while restoring the project files: %s\n", err)
+			}
+			util.Fatal("Interrupted, the project files were restored\n")
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// printDryRunReport prints the files a blueprint would generate and optionally how they differ from the existing files
+func printDryRunReport(generatedBlueprint *blueprint.GeneratedBlueprint, withDiff bool) {
+	util.Print("\nDry run, no files were written. Blueprint output files:\n")
+	for _, file := range generatedBlueprint.Files {
+		if file.Status == blueprint.FileStatusSkipped {
+			util.Print("  %-12s %s\n", file.Status, file.Path)
+		} else {
+			util.Print("  %-12s %s (%d bytes)\n", file.Status, file.Path, file.Size)
+		}
+	}
+
+	if !withDiff {
+		return
+	}
+	for _, file := range generatedBlueprint.Files {
+		if file.Status != blueprint.FileStatusOverwritten {
+			continue
+		}
+		existing, err := ioutil.ReadFile(generatedBlueprint.OutputPath(file.Path))
 		if err != nil {
-			util.Fatal("Error creating local blueprint context: %s\n", err)
+			util.Fatal("Error while reading existing file %s: %s\n", file.Path, err)
+		}
+		util.Print("\n%s", util.UnifiedDiff("a/"+file.Path, "b/"+file.Path, string(existing), file.Content, 3))
+	}
+}
+
+// getAnswersFromFlags returns the answers given with --set and the answer files given with --set-file, each is nil when
+// the flag is not used so that the questions are asked as usual
+func getAnswersFromFlags(sets []string, setFiles []string) (map[string]string, map[string]string) {
+	var answers, answerFiles map[string]string
+	if len(sets) != 0 {
+		answers = parseKeyValuePairs("set", sets)
+	}
+	if len(setFiles) != 0 {
+		answerFiles = parseKeyValuePairs("set-file", setFiles)
+	}
+	for name := range answerFiles {
+		if _, ok := answers[name]; ok {
+			util.Fatal("Parameter %s is given with both --set and --set-file\n", name)
 		}
 	}
+	return answers, answerFiles
+}
 
-	generatedBlueprint := &blueprint.GeneratedBlueprint{OutputDir: models.BlueprintOutputDir}
-	_, _, err = blueprint.InstantiateBlueprint(params, blueprintContext, generatedBlueprint, nil)
+// getBlueprintContext returns the configured blueprint context, or a local one when a local repository directory is given
+func getBlueprintContext(context *xl.Context, localRepoPath string) *blueprint.BlueprintContext {
+	if localRepoPath == "" {
+		return context.BlueprintContext
+	}
+	blueprintContext, err := blueprint.ConstructLocalBlueprintContext(localRepoPath)
 	if err != nil {
-		generatedBlueprint.Cleanup() // Cleanup the partially generated blueprint
-		util.Fatal("Error while creating Blueprint: %s\n", err)
+		util.Fatal("Error creating local blueprint context: %s\n", err)
+	}
+	return blueprintContext
+}
+
+// buildContext reads the CLI configuration and prints it in verbose mode
+func buildContext() *xl.Context {
+	context, err := xl.BuildContext(viper.GetViper(), CliVersion)
+	if err != nil {
+		util.Fatal("Error while reading configuration: %s\n", err)
+	}
+	if util.IsVerbose {
+		context.PrintConfiguration()
 	}
+	return context
 }
 
 func init() {
@@ -58,4 +210,15 @@ func init() {
 	blueprintFlags.StringVarP(&params.AnswersFile, "answers", "a", "", "The file containing answers for blueprint questions")
 	blueprintFlags.BoolVarP(&params.StrictAnswers, "strict-answers", "s", false, "If flag is set, answers file will be expected to have all the variable values")
 	blueprintFlags.BoolVarP(&params.UseDefaultsAsValue, "use-defaults", "d", false, "If flag is set, default values for variables will be treated as value fields")
+	blueprintFlags.StringArrayVar(&answerSets, "set", []string{}, "Answer for a parameter as NAME=VALUE, takes precedence over the answers file, can be repeated")
+	blueprintFlags.StringArrayVar(&answerSetFiles, "set-file", []string{}, "Answer for a parameter read from a file as NAME=PATH, File parameters get the path, takes precedence over the answers file, can be repeated")
+	blueprintFlags.StringArrayVar(&defaultSets, "default", []string{}, "Default value for a parameter with overrideDefault set as NAME=VALUE, can be repeated")
+	blueprintFlags.BoolVar(&envAnswers, "env-answers", false, "Read answers from environment variables named with the prefix followed by the parameter name")
+	blueprintFlags.StringVar(&envAnswersPrefix, "env-answers-prefix", "BLUEPRINT_ANSWER_", "Prefix of the answer environment variables, implies --env-answers")
+	blueprintFlags.StringVar(&saveAnswersFile, "save-answers", "", "Write the answers to a file that can be used with --answers to generate the project again, secrets are not saved")
+	blueprintFlags.StringVar(&outputDir, "output-dir", "", "Directory to generate the blueprint files in, defaults to the current directory")
+	blueprintFlags.StringVar(&params.OnConflict, "on-conflict", "", "What to do with existing files, one of [prompt, overwrite, skip, keep-both, diff, fail], defaults to prompt in interactive runs and fail otherwise")
+	blueprintFlags.BoolVar(&dryRun, "dry-run", false, "Show the files that would be generated without writing anything")
+	blueprintFlags.BoolVar(&showDiff, "diff", false, "Show the differences with the files in the current directory, implies --dry-run")
+	blueprintFlags.StringVarP(&blueprintOutputFormat, "output", "o", outputFormatText, "Output format of the generation result, one of [text, json]")
 }
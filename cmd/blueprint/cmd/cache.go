@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/cache"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of remote blueprint repositories",
+	Long: `Manage the cache of remote blueprint repositories.
+The blueprint list and the files fetched from remote repositories are cached for the latest revision of the
+repository, so that they can be used again with --offline.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached repositories",
+	Long:  "List the cached repositories with their cached revision and the number and size of the cached files",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		repositories, err := cache.ListCachedRepositories(getRepositoryCacheDir())
+		if err != nil {
+			util.Fatal("Error while reading the repository cache: %s\n", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tREVISION\tUPDATED\tFILES\tSIZE")
+		for _, repo := range repositories {
+			updated := time.Unix(repo.Updated, 0).Format("2006-01-02 15:04:05")
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", repo.Name, repo.Provider, repo.Revision, updated, repo.Files, repo.Size)
+		}
+		w.Flush()
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [NAME...]",
+	Short: "Clear the cache",
+	Long:  "Remove the cached content of the named repositories, or of all repositories when no name is given",
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := cache.ClearCache(getRepositoryCacheDir(), args...)
+		if err != nil {
+			util.Fatal("Error while clearing the repository cache: %s\n", err)
+		}
+		for _, name := range args {
+			if !util.IsStringInSlice(strings.ToLower(name), lowerStrings(removed)) {
+				util.Error("Repository '%s' is not in the cache\n", name)
+			}
+		}
+		util.Info("Removed the cached content of %d repositories\n", len(removed))
+	},
+}
+
+// getRepositoryCacheDir returns the cache directory used for the repositories of the configuration file
+func getRepositoryCacheDir() string {
+	configPath, err := util.DefaultConfigfilePath()
+	if err != nil {
+		util.Fatal("Could not get config file location:\n%s", err)
+	}
+	return blueprint.GetRepositoryCacheDir(configPath)
+}
+
+func lowerStrings(values []string) []string {
+	lower := make([]string, len(values))
+	for i, value := range values {
+		lower[i] = strings.ToLower(value)
+	}
+	return lower
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd, cacheClearCmd)
+}
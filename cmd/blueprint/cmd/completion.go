@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+const (
+	completeCommandName    = "__complete"
+	completeBlueprints     = "blueprints"
+	completeRepositories   = "repositories"
+	completionCacheFile    = "blueprint-completion-cache.yaml"
+	blueprintFlagName      = "blueprint"
+	localRepoFlagName      = "local-repo"
+	localRepoFlagShorthand = "l"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Generate a shell completion script",
+	Long: fmt.Sprintf(`Generate a shell completion script, blueprint paths and repository names are completed from the configuration.
+
+  bash: source <(%[1]s completion bash)
+  zsh:  %[1]s completion zsh > "${fpath[1]}/_%[1]s"
+  fish: %[1]s completion fish > ~/.config/fish/completions/%[1]s.fish`, BinaryName),
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := DoCompletion(os.Stdout, args[0]); err != nil {
+			util.Fatal("Error while generating completion script: %s\n", err)
+		}
+	},
+}
+
+// completeCmd is called by the completion scripts to complete flag values
+var completeCmd = &cobra.Command{
+	Use:    completeCommandName + " " + completeBlueprints + "|" + completeRepositories,
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// anything but the values breaks the completion
+		util.IsQuiet = true
+		util.IsVerbose = false
+		for _, value := range getCompletionValues(args[0]) {
+			fmt.Println(value)
+		}
+	},
+}
+
+var completeLocalRepoPath string
+
+// DoCompletion writes the completion script of the shell
+func DoCompletion(w io.Writer, shell string) error {
+	root := getCompletionRootCommand()
+	addCompletionAnnotations(root)
+
+	switch shell {
+	case "bash":
+		root.BashCompletionFunction = fmt.Sprintf(bashCompletionFunction, BinaryName, completeCommandName, blueprint.FlagBlueprintCurrentRepository, localRepoFlagName, localRepoFlagShorthand)
+		return root.GenBashCompletion(w)
+	case "zsh":
+		return genZshCompletion(w, root)
+	case "fish":
+		return genFishCompletion(w, root)
+	default:
+		return fmt.Errorf("shell %s is not supported, it must be one of bash, zsh, fish", shell)
+	}
+}
+
+// getCompletionRootCommand returns a copy of the root command with the flags of the blueprint command, since they can
+// be used without sub-command (see addDefaultCommandIfNeeded). The root command itself is left unchanged.
+func getCompletionRootCommand() *cobra.Command {
+	root := *rootCmd
+	root.ResetFlags()
+	root.PersistentFlags().AddFlagSet(rootCmd.PersistentFlags())
+	root.Flags().AddFlagSet(rootCmd.LocalNonPersistentFlags())
+	root.Flags().AddFlagSet(blueprintCmd.LocalNonPersistentFlags())
+	return &root
+}
+
+// addCompletionAnnotations marks the flags completed with values of the configuration
+func addCompletionAnnotations(command *cobra.Command) {
+	command.PersistentFlags().SetAnnotation(blueprint.FlagBlueprintCurrentRepository, cobra.BashCompCustom, []string{completionFunctionName() + " " + completeRepositories})
+	var annotate func(command *cobra.Command)
+	annotate = func(command *cobra.Command) {
+		if command.Flags().Lookup(blueprintFlagName) != nil {
+			command.Flags().SetAnnotation(blueprintFlagName, cobra.BashCompCustom, []string{completionFunctionName() + " " + completeBlueprints})
+		}
+		for _, child := range command.Commands() {
+			annotate(child)
+		}
+	}
+	annotate(command)
+}
+
+// getCompletionValues returns the blueprint paths or repository names, errors result in no values
+func getCompletionValues(kind string) []string {
+	switch kind {
+	case completeBlueprints:
+		var blueprintContext *blueprint.BlueprintContext
+		cachePath := ""
+		if completeLocalRepoPath != "" {
+			context, err := blueprint.ConstructLocalBlueprintContext(completeLocalRepoPath)
+			if err != nil {
+				return nil
+			}
+			blueprintContext = context
+		} else {
+			context := buildContext()
+			blueprintContext = context.BlueprintContext
+			cachePath = filepath.Join(filepath.Dir(getConfigFilePath()), completionCacheFile)
+		}
+		paths, err := blueprintContext.ListBlueprintPaths(cachePath, time.Now())
+		if err != nil {
+			return nil
+		}
+		return paths
+	case completeRepositories:
+		_, repositories, err := blueprint.GetRepositoriesFromConfig(getConfigFilePath())
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, repo := range repositories {
+			names = append(names, repo["name"])
+		}
+		return names
+	}
+	return nil
+}
+
+func completionFunctionName() string {
+	return "__" + BinaryName + "_complete"
+}
+
+// the repository and local repository flags of the command line are passed on, so that the blueprints are the ones
+// the command would use
+const bashCompletionFunction = `
+__%[1]s_complete()
+{
+    local args=() i
+    for (( i=1; i < ${#words[@]} - 1; i++ )); do
+        case "${words[i]}" in
+            --%[3]s|--%[4]s|-%[5]s)
+                args+=("${words[i]}" "${words[i+1]}")
+                ;;
+            --%[3]s=*|--%[4]s=*)
+                args+=("${words[i]}")
+                ;;
+        esac
+    done
+    local out IFS=$'\n'
+    out=$(%[1]s %[2]s "$1" "${args[@]}" 2>/dev/null) || return
+    COMPREPLY=( $(compgen -W "${out}" -- "${cur}") )
+}
+`
+
+// genZshCompletion writes a zsh completion function per command, cobra only supports the flags without values
+func genZshCompletion(w io.Writer, root *cobra.Command) error {
+	buf := new(bytes.Buffer)
+	name := root.Name()
+	fmt.Fprintf(buf, "#compdef %s\n\n", name)
+	fmt.Fprintf(buf, `%[1]s() {
+  local -a args values
+  local i
+  for (( i=2; i < CURRENT; i++ )); do
+    case "${words[i]}" in
+      --%[3]s|--%[4]s|-%[5]s) args+=("${words[i]}" "${words[i+1]}") ;;
+      --%[3]s=*|--%[4]s=*) args+=("${words[i]}") ;;
+    esac
+  done
+  values=(${(f)"$(%[2]s %[6]s "$1" "${args[@]}" 2>/dev/null)"})
+  compadd -a values
+}
+
+`, completionFunctionName(), name, blueprint.FlagBlueprintCurrentRepository, localRepoFlagName, localRepoFlagShorthand, completeCommandName)
+	writeZshCommandFunction(buf, root, "_"+name)
+	fmt.Fprintf(buf, "_%s \"$@\"\n", name)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeZshCommandFunction(buf *bytes.Buffer, command *cobra.Command, functionName string) {
+	commands := getCompletionCommands(command)
+	fmt.Fprintf(buf, "%s() {\n", functionName)
+	if len(commands) == 0 {
+		fmt.Fprintf(buf, "  _arguments%s\n}\n\n", zshFlagSpecs(command))
+		return
+	}
+
+	fmt.Fprintf(buf, "  local state line\n  _arguments -C%s \\\n    '1: :->cmds' \\\n    '*::arg:->args'\n", zshFlagSpecs(command))
+	fmt.Fprintf(buf, "  case $state in\n    cmds)\n      _values 'command'")
+	for _, child := range commands {
+		fmt.Fprintf(buf, " \\\n        '%s[%s]'", child.Name(), zshEscape(child.Short))
+	}
+	fmt.Fprintf(buf, "\n      ;;\n    args)\n      case $line[1] in\n")
+	for _, child := range commands {
+		fmt.Fprintf(buf, "        %s) %s_%s ;;\n", child.Name(), functionName, child.Name())
+	}
+	fmt.Fprintf(buf, "      esac\n      ;;\n  esac\n}\n\n")
+	for _, child := range commands {
+		writeZshCommandFunction(buf, child, functionName+"_"+child.Name())
+	}
+}
+
+func zshFlagSpecs(command *cobra.Command) string {
+	var specs []string
+	visitCompletionFlags(command, func(flag *pflag.Flag) {
+		action := ""
+		if flag.NoOptDefVal == "" {
+			action = ":" + flag.Name + ":" + zshFlagAction(flag)
+		}
+		usage := zshEscape(flag.Usage)
+		if flag.Shorthand != "" {
+			specs = append(specs, fmt.Sprintf("'(-%s --%s)'{-%s,--%s}'[%s]%s'", flag.Shorthand, flag.Name, flag.Shorthand, flag.Name, usage, action))
+		} else {
+			specs = append(specs, fmt.Sprintf("'--%s[%s]%s'", flag.Name, usage, action))
+		}
+	})
+	if len(specs) == 0 {
+		return ""
+	}
+	return " \\\n    " + strings.Join(specs, " \\\n    ")
+}
+
+func zshFlagAction(flag *pflag.Flag) string {
+	if values, ok := flag.Annotations[cobra.BashCompCustom]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return "_files"
+}
+
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(firstLine(s))
+}
+
+// genFishCompletion writes the fish completions of all commands and flags
+func genFishCompletion(w io.Writer, root *cobra.Command) error {
+	buf := new(bytes.Buffer)
+	name := root.Name()
+	fmt.Fprintf(buf, `function %[1]s
+    set -l args
+    set -l words (commandline -opc)
+    for i in (seq 2 (count $words))
+        switch $words[$i]
+            case --%[3]s --%[4]s -%[5]s
+                set -a args $words[$i] $words[(math $i + 1)]
+            case '--%[3]s=*' '--%[4]s=*'
+                set -a args $words[$i]
+        end
+    end
+    %[2]s %[6]s $argv[1] $args 2>/dev/null
+end
+
+function __%[2]s_using_command
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l path
+    set -l skip 0
+    for word in $words
+        if test $skip -eq 1
+            set skip 0
+        else if contains -- $word %[7]s
+            set skip 1
+        else if not string match -q -- '-*' $word
+            set -a path $word
+        end
+    end
+    test "$path" = "$argv"
+end
+
+complete -c %[2]s -f
+`, completionFunctionName(), name, blueprint.FlagBlueprintCurrentRepository, localRepoFlagName, localRepoFlagShorthand, completeCommandName, strings.Join(getFishValueFlags(root), " "))
+	writeFishCommand(buf, root, nil)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeFishCommand(buf *bytes.Buffer, command *cobra.Command, path []string) {
+	name := command.Root().Name()
+	condition := fmt.Sprintf("__%s_using_command %s", name, strings.Join(path, " "))
+	for _, child := range getCompletionCommands(command) {
+		fmt.Fprintf(buf, "complete -c %s -n '%s' -a %s -d '%s'\n", name, strings.TrimSpace(condition), child.Name(), fishEscape(child.Short))
+	}
+	visitCompletionFlags(command, func(flag *pflag.Flag) {
+		line := fmt.Sprintf("complete -c %s -n '%s' -l %s", name, strings.TrimSpace(condition), flag.Name)
+		if flag.Shorthand != "" {
+			line += " -s " + flag.Shorthand
+		}
+		if flag.NoOptDefVal == "" {
+			if values, ok := flag.Annotations[cobra.BashCompCustom]; ok && len(values) > 0 {
+				line += fmt.Sprintf(" -x -a '(%s)'", values[0])
+			} else {
+				line += " -r -F"
+			}
+		}
+		fmt.Fprintf(buf, "%s -d '%s'\n", line, fishEscape(flag.Usage))
+	})
+	for _, child := range getCompletionCommands(command) {
+		writeFishCommand(buf, child, append(append([]string{}, path...), child.Name()))
+	}
+}
+
+// getFishValueFlags returns the flags followed by a value, so that values are not mistaken for sub-commands
+func getFishValueFlags(command *cobra.Command) []string {
+	var flags []string
+	visitCompletionFlags(command, func(flag *pflag.Flag) {
+		if flag.NoOptDefVal != "" {
+			return
+		}
+		for _, name := range []string{"--" + flag.Name, "-" + flag.Shorthand} {
+			if name != "-" && !util.IsStringInSlice(name, flags) {
+				flags = append(flags, name)
+			}
+		}
+	})
+	for _, child := range getCompletionCommands(command) {
+		for _, name := range getFishValueFlags(child) {
+			if !util.IsStringInSlice(name, flags) {
+				flags = append(flags, name)
+			}
+		}
+	}
+	return flags
+}
+
+func fishEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(firstLine(s))
+}
+
+// getCompletionCommands returns the sub-commands to complete, hidden commands and help are left out
+func getCompletionCommands(command *cobra.Command) []*cobra.Command {
+	var commands []*cobra.Command
+	for _, child := range command.Commands() {
+		if child.IsAvailableCommand() && child.Name() != "help" {
+			commands = append(commands, child)
+		}
+	}
+	return commands
+}
+
+// visitCompletionFlags visits the flags of a command, inherited flags included
+func visitCompletionFlags(command *cobra.Command, fn func(flag *pflag.Flag)) {
+	visit := func(flag *pflag.Flag) {
+		if !flag.Hidden && flag.Deprecated == "" {
+			fn(flag)
+		}
+	}
+	command.NonInheritedFlags().VisitAll(visit)
+	command.InheritedFlags().VisitAll(visit)
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(completeCmd)
+
+	completeCmd.Flags().StringVarP(&completeLocalRepoPath, localRepoFlagName, localRepoFlagShorthand, "", "Local repository directory to complete the blueprints of")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{
+			`flags_completion+=("__xl-blueprint_complete blueprints")`,
+			`flags_completion+=("__xl-blueprint_complete repositories")`,
+			`out=$(xl-blueprint __complete "$1" "${args[@]}" 2>/dev/null) || return`,
+		}},
+		{"zsh", []string{
+			"#compdef xl-blueprint",
+			`'(-b --blueprint)'{-b,--blueprint}'[Blueprint path to use, relative to the active repository]:blueprint:__xl-blueprint_complete blueprints'`,
+			"upgrade) _xl-blueprint_upgrade ;;",
+		}},
+		{"fish", []string{
+			"complete -c xl-blueprint -n '__xl-blueprint_using_command' -l blueprint -s b -x -a '(__xl-blueprint_complete blueprints)'",
+			"complete -c xl-blueprint -n '__xl-blueprint_using_command repo' -a add -d 'Add a repository'",
+			"complete -c xl-blueprint -n '__xl-blueprint_using_command describe' -l blueprint-current-repository -x -a '(__xl-blueprint_complete repositories)'",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			require.Nil(t, DoCompletion(buf, tt.shell))
+			for _, want := range tt.want {
+				assert.Contains(t, buf.String(), want)
+			}
+			assert.NotContains(t, buf.String(), completeCommandName+" "+completeBlueprints+"|")
+		})
+	}
+
+	t.Run("should leave the root command unchanged", func(t *testing.T) {
+		require.Nil(t, DoCompletion(new(bytes.Buffer), "bash"))
+		assert.Nil(t, rootCmd.Flags().Lookup(blueprintFlagName))
+		assert.Empty(t, rootCmd.BashCompletionFunction)
+	})
+
+	err := DoCompletion(new(bytes.Buffer), "tcsh")
+	require.NotNil(t, err)
+	assert.Equal(t, "shell tcsh is not supported, it must be one of bash, zsh, fish", err.Error())
+}
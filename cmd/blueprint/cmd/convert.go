@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert BLUEPRINT_FILE",
+	Short: "Convert an xl/v1 blueprint definition to xl/v2",
+	Long: `Rewrite an xl/v1 blueprint definition file as an xl/v2 definition and print it.
+Functions (!fn) are replaced with the equivalent expressions (!expr), dependsOn, dependsOnTrue and dependsOnFalse
+become promptIf and writeIf conditions, and renamed parameter fields are updated. Comments are kept.
+Everything that cannot be converted automatically is reported and must be fixed by hand.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		DoConvert(args[0])
+	},
+}
+
+var convertWrite bool
+
+// DoConvert converts the given blueprint definition file and prints the result, or writes it back to the file
+func DoConvert(path string) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		util.Fatal("Error while reading blueprint definition: %s\n", err)
+	}
+	conversion, err := blueprint.ConvertBlueprintV1(content)
+	if err != nil {
+		util.Fatal("Error while converting blueprint definition %s: %s\n", path, err)
+	}
+
+	if convertWrite {
+		info, err := os.Stat(path)
+		if err == nil {
+			err = ioutil.WriteFile(path, conversion.Content, info.Mode())
+		}
+		if err != nil {
+			util.Fatal("Error while writing blueprint definition: %s\n", err)
+		}
+		util.Info("Converted %s to xl/v2\n", path)
+	} else {
+		os.Stdout.Write(conversion.Content)
+	}
+	for _, warning := range conversion.Warnings {
+		util.Error("warning: %s\n", warning)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertFlags := convertCmd.Flags()
+	convertFlags.BoolVarP(&convertWrite, "write", "w", false, "Write the converted definition to the blueprint file instead of printing it")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Describe the questions and files of a blueprint",
+	Long: `Describe the full question set and file list of a blueprint, including all included blueprints,
+without asking any question`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		validateOutputFormat(describeOutputFormat, outputFormatText, outputFormatJSON, outputFormatMD)
+		startJSONOutput(describeOutputFormat)
+		context := buildContext()
+		DoDescribe(getBlueprintContext(context, describeLocalRepoPath), describeBlueprintPath)
+	},
+}
+
+var describeLocalRepoPath string
+var describeBlueprintPath string
+var describeOutputFormat string
+
+// DoDescribe prints the composed description of a blueprint
+func DoDescribe(blueprintContext *blueprint.BlueprintContext, blueprintPath string) {
+	description, err := blueprintContext.DescribeBlueprint(blueprintPath)
+	if err != nil {
+		util.Fatal("Error while describing blueprint: %s\n", err)
+	}
+
+	switch describeOutputFormat {
+	case outputFormatJSON:
+		printJSON(description)
+	case outputFormatMD:
+		if err := description.WriteMarkdown(os.Stdout); err != nil {
+			util.Fatal("Error while writing Markdown output: %s\n", err)
+		}
+	default:
+		printDescription(description)
+	}
+}
+
+func printDescription(description *blueprint.BlueprintDescription) {
+	util.Print("Blueprint:   %s\n", description.Path)
+	util.Print("Name:        %s\n", description.Name)
+	util.Print("Version:     %s\n", description.Version)
+	util.Print("Author:      %s\n", description.Author)
+	if description.Description != "" {
+		util.Print("Description: %s\n", indentLines(description.Description, 13))
+	}
+
+	util.Print("\nParameters:\n")
+	for _, parameter := range description.Parameters {
+		util.Print("  %s\n", parameter.Name)
+		printDescriptionField("type", parameter.Type)
+		printDescriptionField("prompt", parameter.Prompt)
+		printDescriptionField("value", parameter.Value)
+		printDescriptionField("default", parameter.Default)
+		printDescriptionField("options", strings.Join(parameter.Options, ", "))
+		printDescriptionField("validate", parameter.Validate)
+		printDescriptionField("promptIf", parameter.PromptIf)
+		printDescriptionField("includeIf", strings.Join(parameter.IncludeIf, " and "))
+		if parameter.SaveInXlvals {
+			printDescriptionField("saveInXlvals", "true")
+		}
+		printDescriptionField("blueprint", parameter.Blueprint)
+	}
+
+	util.Print("\nFiles:\n")
+	for _, file := range description.Files {
+		util.Print("  %s\n", file.Path)
+		printDescriptionField("renameTo", file.RenameTo)
+		printDescriptionField("writeIf", file.WriteIf)
+		printDescriptionField("includeIf", strings.Join(file.IncludeIf, " and "))
+		printDescriptionField("blueprint", file.Blueprint)
+	}
+}
+
+func printDescriptionField(name string, value string) {
+	if value != "" {
+		util.Print("    %-13s %s\n", name+":", indentLines(strings.TrimSpace(value), 18))
+	}
+}
+
+// indentLines indents all but the first line so that multi-line values stay aligned
+func indentLines(value string, indent int) string {
+	return strings.Replace(value, "\n", "\n"+fmt.Sprintf("%*s", indent, ""), -1)
+}
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+
+	describeFlags := describeCmd.Flags()
+	describeFlags.StringVarP(&describeBlueprintPath, "blueprint", "b", "", "Blueprint path to describe, relative to the active repository")
+	describeFlags.StringVarP(&describeLocalRepoPath, "local-repo", "l", "", "Local repository directory to use (bypasses active repository)")
+	describeFlags.StringVarP(&describeOutputFormat, "output", "o", outputFormatText, "Output format, one of [text, json, markdown]")
+	describeCmd.MarkFlagRequired("blueprint")
+}
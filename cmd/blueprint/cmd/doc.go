@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var docCmd = &cobra.Command{
+	Use:   "doc",
+	Short: "Generate Markdown documentation for a blueprint or a repository",
+	Long: `Generate Markdown documentation from the composed blueprint: its metadata and instructions, all parameters with
+their prompt, description, default, options, validation and conditions, the files written on each condition and the
+included blueprints.
+With --index, a document is generated for every blueprint of the repository together with an index page.`,
+	Example: `  xl-blueprint doc -b aws/monolith > README.md
+  xl-blueprint doc -l ./blueprints --index -d ./docs`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if docBlueprintPath == "" && !docIndex {
+			util.Fatal("Either a blueprint path with --blueprint, or --index is required\n")
+		}
+		if docBlueprintPath != "" && docIndex {
+			util.Fatal("--blueprint and --index cannot be used together\n")
+		}
+		context := buildContext()
+		blueprintContext := getBlueprintContext(context, docLocalRepoPath)
+		if docIndex {
+			DoDocIndex(blueprintContext, docOutputDir)
+		} else {
+			DoDoc(blueprintContext, docBlueprintPath, docOutputDir)
+		}
+	},
+}
+
+var docLocalRepoPath string
+var docBlueprintPath string
+var docOutputDir string
+var docIndex bool
+
+// DoDoc writes the Markdown document of a blueprint to stdout, or to the output directory when one is given
+func DoDoc(blueprintContext *blueprint.BlueprintContext, blueprintPath string, outputDir string) {
+	repoContext, blueprintPath, err := blueprintContext.ResolveBlueprintPath(blueprintPath)
+	if err != nil {
+		util.Fatal("Error while generating documentation: %s\n", err)
+	}
+	description, err := repoContext.DescribeBlueprint(blueprintPath)
+	if err != nil {
+		util.Fatal("Error while generating documentation: %s\n", err)
+	}
+
+	if outputDir == "" {
+		if err := description.WriteMarkdown(os.Stdout); err != nil {
+			util.Fatal("Error while writing Markdown output: %s\n", err)
+		}
+		return
+	}
+	docPath := filepath.Join(outputDir, filepath.FromSlash(blueprint.MarkdownDocPath(blueprintPath)))
+	if err := os.MkdirAll(filepath.Dir(docPath), 0755); err != nil {
+		util.Fatal("Error while writing documentation: %s\n", err)
+	}
+	file, err := os.Create(docPath)
+	if err != nil {
+		util.Fatal("Error while writing documentation: %s\n", err)
+	}
+	defer file.Close()
+	if err := description.WriteMarkdown(file); err != nil {
+		util.Fatal("Error while writing documentation: %s\n", err)
+	}
+	util.Info("Documentation written to %s\n", docPath)
+}
+
+// DoDocIndex writes the index page of the repository to stdout, or the documents of all blueprints together with the
+// index page to the output directory when one is given
+func DoDocIndex(blueprintContext *blueprint.BlueprintContext, outputDir string) {
+	if outputDir == "" {
+		summaries, err := blueprintContext.ListBlueprints("")
+		if err != nil {
+			util.Fatal("Error while generating documentation: %s\n", err)
+		}
+		if err := blueprint.WriteMarkdownIndex(os.Stdout, (*blueprintContext.ActiveRepo).GetName(), summaries); err != nil {
+			util.Fatal("Error while writing Markdown output: %s\n", err)
+		}
+		return
+	}
+
+	written, err := blueprintContext.WriteRepositoryMarkdown(outputDir)
+	if err != nil {
+		util.Fatal("Error while generating documentation: %s\n", err)
+	}
+	for _, docPath := range written {
+		util.Verbose("Written %s\n", filepath.Join(outputDir, docPath))
+	}
+	util.Info("Documentation of %d blueprint(s) written to %s\n", len(written)-1, outputDir)
+}
+
+func init() {
+	rootCmd.AddCommand(docCmd)
+
+	docFlags := docCmd.Flags()
+	docFlags.StringVarP(&docBlueprintPath, "blueprint", "b", "", "Blueprint path to document, relative to the active repository")
+	docFlags.StringVarP(&docLocalRepoPath, "local-repo", "l", "", "Local repository directory to use (bypasses active repository)")
+	docFlags.StringVarP(&docOutputDir, "output-dir", "d", "", "Directory to write the Markdown documents to, instead of printing to stdout")
+	docFlags.BoolVar(&docIndex, "index", false, "Document the whole repository with an index page linking to the document of every blueprint")
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"github.com/xebialabs/yaml"
+)
+
+var extractCmd = &cobra.Command{
+	Use:   "extract PROJECT_DIR",
+	Short: "Extract a new blueprint from an existing project directory",
+	Long: `Create a blueprint from the files of an existing project.
+Every value given with --param or --params-file is replaced with a {{.Parameter}} placeholder in the project files,
+files containing a value are renamed to .tmpl, and a blueprint.yaml is written with a parameter for each value,
+defaulting to that value, and a files entry for every file.`,
+	Example: `  xl-blueprint extract ./my-app -p AppName=my-app -p Region=eu-west-1 -d ./blueprints/my-app`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		values, err := getExtractValues(extractParamsFile, extractParams)
+		if err != nil {
+			util.Fatal("Error while reading parameters: %s\n", err)
+		}
+		targetDir := extractBlueprintDir
+		if targetDir == "" {
+			targetDir = filepath.Clean(args[0]) + "-blueprint"
+		}
+		DoExtract(blueprint.ExtractParams{SourceDir: args[0], TargetDir: targetDir, Name: extractName, Values: values})
+	},
+}
+
+var extractParams []string
+var extractParamsFile string
+var extractBlueprintDir string
+var extractName string
+
+// DoExtract extracts a blueprint from a project directory and prints what was written
+func DoExtract(params blueprint.ExtractParams) {
+	result, err := blueprint.ExtractBlueprint(params)
+	if err != nil {
+		util.Fatal("Error while extracting blueprint: %s\n", err)
+	}
+
+	util.Info("Blueprint written to %s with %d file(s), %d template(s)\n", params.TargetDir, len(result.Files), len(result.Templates))
+	for _, value := range params.Values {
+		if result.Occurrences[value.Parameter] == 0 {
+			util.Error("warning: value of parameter [%s] was not found in any file\n", value.Parameter)
+		} else {
+			util.Info("  %s: %d occurrence(s)\n", value.Parameter, result.Occurrences[value.Parameter])
+		}
+	}
+}
+
+// getExtractValues reads the parameters of the params file followed by the NAME=VALUE pairs, keeping their order
+func getExtractValues(paramsFile string, pairs []string) ([]blueprint.ExtractValue, error) {
+	values := make([]blueprint.ExtractValue, 0)
+	if paramsFile != "" {
+		content, err := ioutil.ReadFile(paramsFile)
+		if err != nil {
+			return nil, err
+		}
+		params := yaml.MapSlice{}
+		if err := yaml.Unmarshal(content, &params); err != nil {
+			return nil, fmt.Errorf("%s is not a valid parameters file: %s", paramsFile, err)
+		}
+		for _, param := range params {
+			values = append(values, blueprint.ExtractValue{Parameter: fmt.Sprint(param.Key), Literal: fmt.Sprint(param.Value)})
+		}
+	}
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid value '%s' for --param, expected format is NAME=VALUE", pair)
+		}
+		values = append(values, blueprint.ExtractValue{Parameter: strings.TrimSpace(kv[0]), Literal: kv[1]})
+	}
+	return values, nil
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+
+	extractFlags := extractCmd.Flags()
+	extractFlags.StringArrayVarP(&extractParams, "param", "p", []string{}, "Parameter as NAME=VALUE, every VALUE in the project files is replaced with {{.NAME}}, can be repeated")
+	extractFlags.StringVarP(&extractParamsFile, "params-file", "f", "", "YAML file mapping parameter names to the values they replace")
+	extractFlags.StringVarP(&extractBlueprintDir, "blueprint-dir", "d", "", "Directory to write the blueprint to (default \"PROJECT_DIR-blueprint\")")
+	extractFlags.StringVarP(&extractName, "name", "n", "", "Name of the blueprint (default is the project directory name)")
+}
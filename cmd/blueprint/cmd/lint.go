@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint PATH...",
+	Short: "Check blueprint definitions for problems",
+	Long: `Check the blueprints in the given local directories and report every problem found.
+A directory without a blueprint definition file is searched for blueprints recursively.
+Included blueprints are looked up in the active repository, or in the repository given with --local-repo.
+The command exits with a non-zero status when any error is found.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		validateOutputFormat(lintOutputFormat, outputFormatText, outputFormatJSON)
+		startJSONOutput(lintOutputFormat)
+		context := buildContext()
+		DoLint(getBlueprintContext(context, lintLocalRepoPath), args)
+	},
+}
+
+var lintLocalRepoPath string
+var lintOutputFormat string
+
+// DoLint checks the blueprints in the given paths and exits with status 1 when errors are found
+func DoLint(blueprintContext *blueprint.BlueprintContext, paths []string) {
+	issues := make([]blueprint.LintIssue, 0)
+	for _, path := range paths {
+		pathIssues, err := blueprintContext.LintBlueprints(path)
+		if err != nil {
+			util.Fatal("Error while checking blueprints in %s: %s\n", path, err)
+		}
+		issues = append(issues, pathIssues...)
+	}
+
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Severity == blueprint.LintSeverityError {
+			errorCount++
+		}
+	}
+
+	if lintOutputFormat == outputFormatJSON {
+		printJSON(issues)
+	} else {
+		for _, issue := range issues {
+			util.Print("%s: %s: %s [%s]\n", filepath.Join(issue.Blueprint, issue.File), issue.Severity, issue.Message, issue.Rule)
+		}
+		util.Info("%d problem(s) found, %d error(s)\n", len(issues), errorCount)
+	}
+
+	if errorCount > 0 {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintFlags := lintCmd.Flags()
+	lintFlags.StringVarP(&lintLocalRepoPath, "local-repo", "l", "", "Local repository directory used to resolve included blueprints (bypasses active repository)")
+	lintFlags.StringVarP(&lintOutputFormat, "output", "o", outputFormatText, "Output format, one of [text, json]")
+}
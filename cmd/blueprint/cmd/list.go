@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List blueprints in the active repository",
+	Long:  "List the blueprints available in the active repository together with their metadata",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		validateOutputFormat(listOutputFormat, outputFormatTable, outputFormatJSON)
+		startJSONOutput(listOutputFormat)
+		context := buildContext()
+		DoList(getBlueprintContext(context, listLocalRepoPath))
+	},
+}
+
+var listLocalRepoPath string
+var listPathPrefix string
+var listOutputFormat string
+
+// DoList prints the blueprints of the active repository
+func DoList(blueprintContext *blueprint.BlueprintContext) {
+	summaries, err := blueprintContext.ListBlueprints(listPathPrefix)
+	if err != nil {
+		util.Fatal("Error while listing blueprints: %s\n", err)
+	}
+
+	if listOutputFormat == outputFormatJSON {
+		printJSON(summaries)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tNAME\tVERSION\tAUTHOR\tFRAGMENT\tDESCRIPTION")
+	for _, summary := range summaries {
+		description := firstLine(summary.Description)
+		if summary.Error != "" {
+			description = fmt.Sprintf("(invalid: %s)", firstLine(summary.Error))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", summary.Path, summary.Name, summary.Version, summary.Author, summary.Fragment, description)
+	}
+	w.Flush()
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(strings.TrimSpace(s), "\n", 2)[0]
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listFlags := listCmd.Flags()
+	listFlags.StringVarP(&listLocalRepoPath, "local-repo", "l", "", "Local repository directory to use (bypasses active repository)")
+	listFlags.StringVarP(&listPathPrefix, "prefix", "p", "", "Only list blueprints whose path starts with the given prefix")
+	listFlags.StringVarP(&listOutputFormat, "output", "o", outputFormatTable, "Output format, one of [table, json]")
+}
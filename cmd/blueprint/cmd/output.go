@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+// Output formats supported by the commands that print structured results
+const (
+	outputFormatTable = "table"
+	outputFormatText  = "text"
+	outputFormatJSON  = "json"
+	outputFormatMD    = "markdown"
+)
+
+func validateOutputFormat(format string, supported ...string) {
+	if !util.IsStringInSlice(format, supported) {
+		util.Fatal("Unsupported output format '%s', supported formats are %v\n", format, supported)
+	}
+}
+
+// printJSON writes the given value as indented JSON, this is not suppressed in quiet mode
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		util.Fatal("Error while formatting JSON output: %s\n", err)
+	}
+	util.Print("%s\n", data)
+}
+
+// jsonError is the document printed instead of the result when a command with JSON output fails
+type jsonError struct {
+	Error          string                   `json:"error"`
+	TemplateErrors blueprint.TemplateErrors `json:"templateErrors,omitempty"`
+}
+
+// fatalOutput reports an error and exits with a non-zero status. With JSON output the error is printed to stdout as a
+// {"error": ...} document, so that a pipeline reading stdout always gets a JSON document.
+func fatalOutput(outputFormat string, err error, format string, a ...interface{}) {
+	if outputFormat != outputFormatJSON {
+		util.Fatal(format, a...)
+	}
+	document := jsonError{Error: strings.TrimSpace(fmt.Sprintf(format, a...))}
+	if templateErrors, ok := err.(blueprint.TemplateErrors); ok {
+		document.TemplateErrors = templateErrors
+	}
+	printJSON(document)
+	os.Exit(1)
+}
+
+// startJSONOutput suppresses the informational output so that only the JSON document reaches stdout
+func startJSONOutput(format string) {
+	if format == outputFormatJSON {
+		util.IsQuiet = true
+		util.IsVerbose = false
+	}
+}
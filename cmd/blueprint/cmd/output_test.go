@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+)
+
+func TestDoBlueprintJSONError(t *testing.T) {
+	if os.Getenv("BLUEPRINT_TEST_JSON_ERROR") != "" {
+		// the failing generation exits the process, it runs in a child process started below
+		blueprintOutputFormat = outputFormatJSON
+		startJSONOutput(blueprintOutputFormat)
+		localRepoPath = os.Getenv("BLUEPRINT_TEST_JSON_ERROR")
+		outputDir = filepath.Join(localRepoPath, "out")
+		params = blueprint.BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{}, StrictAnswers: true}
+		DoBlueprint(nil)
+		return
+	}
+
+	repoDir, err := ioutil.TempDir("", "blueprintcmd")
+	require.Nil(t, err)
+	defer os.RemoveAll(repoDir)
+	require.Nil(t, os.MkdirAll(filepath.Join(repoDir, "app"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(repoDir, "app", "blueprint.yaml"), []byte("apiVersion: xl/v2\nkind: Blueprint\nspec:\n  files:\n  - path: app.yaml.tmpl\n"), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(repoDir, "app", "app.yaml.tmpl"), []byte("name: {{.AppName}"), 0644))
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestDoBlueprintJSONError")
+	cmd.Env = append(os.Environ(), "BLUEPRINT_TEST_JSON_ERROR="+repoDir)
+	output, err := cmd.Output()
+	exitErr, ok := err.(*exec.ExitError)
+	require.True(t, ok, "the command should fail, got %v", err)
+	assert.False(t, exitErr.Success())
+
+	var result jsonError
+	require.Nil(t, json.Unmarshal(output, &result), "stdout should be a JSON document: %s", output)
+	assert.Contains(t, result.Error, "Error while creating Blueprint: cannot parse template of blueprint [app] at [app/app.yaml.tmpl] line 1")
+	require.Len(t, result.TemplateErrors, 1)
+	assert.Equal(t, "app/app.yaml.tmpl", result.TemplateErrors[0].Path)
+	_, err = os.Stat(filepath.Join(repoDir, "out", "xebialabs"))
+	assert.True(t, os.IsNotExist(err))
+}
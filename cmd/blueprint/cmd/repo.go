@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Manage blueprint repositories",
+	Long:  "Manage the blueprint repositories defined in the CLI configuration file",
+}
+
+var repoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured repositories",
+	Long:  "List the configured blueprint repositories, the current repository is marked with '*'",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		currentRepo, repositories, err := blueprint.GetRepositoriesFromConfig(getConfigFilePath())
+		if err != nil {
+			util.Fatal("Error while reading repositories: %s\n", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CURRENT\tNAME\tTYPE")
+		for _, repo := range repositories {
+			current := ""
+			if strings.EqualFold(repo["name"], currentRepo) {
+				current = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", current, repo["name"], repo["type"])
+		}
+		w.Flush()
+	},
+}
+
+var repoAddCmd = &cobra.Command{
+	Use:   "add NAME",
+	Short: "Add a repository",
+	Long: `Add a blueprint repository to the configuration file.
+Provider specific fields are given with --param, for example:
+  repo add "My GitHub" --type github --param owner=my-org --param repo-name=blueprints --param branch=master
+  repo add "My Local" --type local --param path=~/blueprints`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		repoDefinition := blueprint.ConfMap(parseKeyValuePairs("param", repoAddParams))
+		repoDefinition["name"] = args[0]
+		repoDefinition["type"] = repoAddType
+		err := blueprint.AddRepositoryToConfig(getConfigFilePath(), repoDefinition, repoAddUse, CliVersion)
+		if err != nil {
+			util.Fatal("Error while adding repository: %s\n", err)
+		}
+		util.Info("Repository '%s' added\n", args[0])
+	},
+}
+
+var repoRemoveCmd = &cobra.Command{
+	Use:   "remove NAME",
+	Short: "Remove a repository",
+	Long:  "Remove a blueprint repository from the configuration file, the default repository cannot be removed",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := blueprint.RemoveRepositoryFromConfig(getConfigFilePath(), args[0])
+		if err != nil {
+			util.Fatal("Error while removing repository: %s\n", err)
+		}
+		util.Info("Repository '%s' removed\n", args[0])
+	},
+}
+
+var repoUseCmd = &cobra.Command{
+	Use:   "use NAME",
+	Short: "Set the current repository",
+	Long:  "Set the blueprint repository that is used when no other repository is specified",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := blueprint.SetCurrentRepositoryInConfig(getConfigFilePath(), args[0])
+		if err != nil {
+			util.Fatal("Error while setting current repository: %s\n", err)
+		}
+		util.Info("Current repository is set to '%s'\n", args[0])
+	},
+}
+
+var repoShowCmd = &cobra.Command{
+	Use:   "show [NAME]",
+	Short: "Show repository details",
+	Long:  "Show the details of a blueprint repository, or of the current repository when no name is given",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath := getConfigFilePath()
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		} else {
+			currentRepo, _, err := blueprint.GetRepositoriesFromConfig(configPath)
+			if err != nil {
+				util.Fatal("Error while reading repositories: %s\n", err)
+			}
+			name = currentRepo
+		}
+		repoDefinition, err := blueprint.GetRepositoryFromConfig(configPath, name)
+		if err != nil {
+			util.Fatal("Error while reading repository: %s\n", err)
+		}
+		repo, err := blueprint.NewBlueprintRepository(repoDefinition, CliVersion)
+		if err != nil {
+			util.Fatal("Error in repository definition: %s\n", err)
+		}
+		util.Print("%s\n", repo.GetInfo())
+	},
+}
+
+var repoAddType string
+var repoAddParams []string
+var repoAddUse bool
+
+// getConfigFilePath returns the configuration file in use, which is always set after initConfig
+func getConfigFilePath() string {
+	if configFile := viper.ConfigFileUsed(); configFile != "" {
+		return configFile
+	}
+	configFile, err := util.DefaultConfigfilePath()
+	if err != nil {
+		util.Fatal("Could not get config file location:\n%s", err)
+	}
+	return configFile
+}
+
+// parseKeyValuePairs parses repeated KEY=VALUE flag values into a map
+func parseKeyValuePairs(flagName string, pairs []string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			util.Fatal("Invalid value '%s' for --%s, expected format is KEY=VALUE\n", pair, flagName)
+		}
+		values[strings.TrimSpace(kv[0])] = kv[1]
+	}
+	return values
+}
+
+func init() {
+	rootCmd.AddCommand(repoCmd)
+	repoCmd.AddCommand(repoListCmd, repoAddCmd, repoRemoveCmd, repoUseCmd, repoShowCmd)
+
+	repoAddFlags := repoAddCmd.Flags()
+	repoAddFlags.StringVarP(&repoAddType, "type", "t", "", "Repository provider type, one of [local, github, gitlab, bitbucket, bitbucketserver, http]")
+	repoAddFlags.StringArrayVarP(&repoAddParams, "param", "p", []string{}, "Provider specific repository field as KEY=VALUE, can be repeated")
+	repoAddFlags.BoolVar(&repoAddUse, "use", false, "Set the added repository as the current repository")
+	repoAddCmd.MarkFlagRequired("type")
+}
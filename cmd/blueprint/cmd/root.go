@@ -31,13 +31,9 @@ var rootCmd = &cobra.Command{
 }
 
 func hasSubCommand(args []string) bool {
-	argsString := strings.Join(args, " ")
-	for _, cmd := range rootCmd.Commands() {
-		if strings.Contains(argsString, cmd.Use) {
-			return true
-		}
-	}
-	return false
+	// let cobra resolve the command so that flag values and command arguments are not mistaken for sub-commands
+	cmd, _, err := rootCmd.Find(args[1:])
+	return err == nil && cmd != rootCmd
 }
 
 func addDefaultCommandIfNeeded(args []string) []string {
@@ -42,6 +42,16 @@ func Test_addDefaultCommandIfNeeded(t *testing.T) {
 			[]string{"xl-bp", "-v"},
 			[]string{"xl-bp", "blueprint", "-v"},
 		},
+		{
+			"get args as is when a subcommand with arguments is included",
+			[]string{"xl-bp", "list", "-p", "aws"},
+			[]string{"xl-bp", "list", "-p", "aws"},
+		},
+		{
+			"get default when a flag value matches a subcommand name",
+			[]string{"xl-bp", "-b", "list"},
+			[]string{"xl-bp", "blueprint", "-b", "list"},
+		},
 		{
 			"get default when command with multiple flags",
 			[]string{"xl-bp", "-v", "-h"},
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"github.com/xebialabs/blueprint-cli/pkg/xl"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search TERM",
+	Short: "Search blueprints in all configured repositories",
+	Long: `Search the blueprints of all configured repositories for the given term.
+A blueprint matches when its path, name, description or one of its tags contains the term, ignoring case.
+A blueprint found in another repository than the active one can be generated with -b repo-name:blueprint/path.`,
+	Example: `  xl-blueprint search microservice
+  xl-blueprint -b "XL Github:aws/microservice-ecommerce"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		validateOutputFormat(searchOutputFormat, outputFormatTable, outputFormatJSON)
+		startJSONOutput(searchOutputFormat)
+		DoSearch(buildContext(), args[0])
+	},
+}
+
+var searchOutputFormat string
+
+// DoSearch prints the blueprints of all repositories matching the term
+func DoSearch(context *xl.Context, term string) {
+	results, errs := context.BlueprintContext.SearchBlueprints(term)
+	for _, err := range errs {
+		util.Error("Error while searching %s\n", err)
+	}
+	if len(errs) > 0 && len(errs) == len(context.BlueprintContext.DefinedRepos) {
+		util.Fatal("None of the repositories could be searched\n")
+	}
+
+	if searchOutputFormat == outputFormatJSON {
+		printJSON(results)
+		return
+	}
+	if len(results) == 0 {
+		util.Info("No blueprints found matching '%s'\n", term)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tPATH\tNAME\tVERSION\tDESCRIPTION")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Repository, result.Path, result.Name, result.Version, firstLine(result.Description))
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchFlags := searchCmd.Flags()
+	searchFlags.StringVarP(&searchOutputFormat, "output", "o", outputFormatTable, "Output format, one of [table, json]")
+}
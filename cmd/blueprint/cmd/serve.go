@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the blueprint repositories over a REST API",
+	Long: `Start an HTTP server with a REST API over the configured repositories: list the repositories and their
+blueprints, get the parameters of a blueprint, validate answers and render a blueprint from answers to a zip or tar
+archive. No question is ever asked, answers that are missing or not valid are reported as errors.`,
+	Example: `  xl-blueprint serve --listen localhost:8080
+  curl -X POST localhost:8080/api/v1/render -d '{"blueprint": "aws/monolith", "answers": {"AppName": "demo"}}' -o demo.zip`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		context := buildContext()
+		DoServe(getBlueprintContext(context, serveLocalRepoPath), serveListenAddress)
+	},
+}
+
+var serveLocalRepoPath string
+var serveListenAddress string
+
+// DoServe serves the blueprint REST API until the process is stopped
+func DoServe(blueprintContext *blueprint.BlueprintContext, listenAddress string) {
+	util.Info("Serving blueprints of repository %s on %s\n", (*blueprintContext.ActiveRepo).GetName(), listenAddress)
+	if err := http.ListenAndServe(listenAddress, blueprint.NewBlueprintServer(blueprintContext)); err != nil {
+		util.Fatal("Error while serving blueprints: %s\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveFlags := serveCmd.Flags()
+	serveFlags.StringVar(&serveListenAddress, "listen", "localhost:8080", "Address to listen on, as host:port, use :8080 to listen on all interfaces")
+	serveFlags.StringVarP(&serveLocalRepoPath, "local-repo", "l", "", "Local repository directory to serve (bypasses active repository)")
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [BLUEPRINT...]",
+	Short: "Run blueprint test cases",
+	Long: `Run the test cases found in the __test__ directory of blueprints.
+Every __test__/test-case-*.yaml file is run by generating the blueprint non-interactively in a temporary directory
+with the answers of the test case, and checking the generated files and values.
+The blueprints are looked up in all configured repositories, or only in the one given with --repository.
+All blueprints having test cases are tested when no blueprint path is given.
+Test cases with a golden-dir field compare the generated files with the files in that directory, use
+--update-golden to rewrite those files with the current output (local repositories only).
+The command exits with a non-zero status when any test case fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		context := buildContext()
+		blueprintContext := getBlueprintContext(context, testLocalRepoPath)
+		DoTest(blueprintContext, testRepository, args)
+	},
+}
+
+var testLocalRepoPath string
+var testRepository string
+var testJUnitFile string
+var testUpdateGolden bool
+
+// DoTest runs the test cases of the given blueprints and exits with status 1 when any of them fails
+func DoTest(blueprintContext *blueprint.BlueprintContext, repositoryName string, blueprintPaths []string) {
+	// keep the generation output out of the test report unless asked for
+	isQuiet := util.IsQuiet
+	util.IsQuiet = !util.IsVerbose
+	results, err := blueprintContext.RunBlueprintTests(repositoryName, blueprintPaths, testUpdateGolden)
+	util.IsQuiet = isQuiet
+	if err != nil {
+		util.Fatal("Error while running blueprint tests: %s\n", err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Passed() {
+			goldenUpdated := ""
+			if result.GoldenUpdated {
+				goldenUpdated = ", golden files updated"
+			}
+			util.Print("PASS  %s:%s/%s (%.2fs%s)\n", result.Repository, result.Blueprint, result.TestCase, result.Duration.Seconds(), goldenUpdated)
+			continue
+		}
+		failed++
+		util.Print("FAIL  %s:%s/%s (%.2fs)\n", result.Repository, result.Blueprint, result.TestCase, result.Duration.Seconds())
+		if result.Error != "" {
+			util.Print("      error: %s\n", result.Error)
+		}
+		for _, failure := range result.Failures {
+			util.Print("      %s\n", strings.Replace(failure, "\n", "\n      ", -1))
+		}
+	}
+	util.Print("%d test case(s), %d passed, %d failed\n", len(results), len(results)-failed, failed)
+
+	if testJUnitFile != "" {
+		f, err := os.Create(testJUnitFile)
+		if err != nil {
+			util.Fatal("Error while creating JUnit report: %s\n", err)
+		}
+		err = blueprint.WriteJUnitReport(results, f)
+		f.Close()
+		if err != nil {
+			util.Fatal("Error while writing JUnit report: %s\n", err)
+		}
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	testFlags := testCmd.Flags()
+	testFlags.StringVarP(&testLocalRepoPath, "local-repo", "l", "", "Local repository directory to use (bypasses active repository)")
+	testFlags.StringVarP(&testRepository, "repository", "r", "", "Name of the configured repository to test instead of all configured repositories")
+	testFlags.StringVar(&testJUnitFile, "junit", "", "Write the test results as JUnit XML to the given file")
+	testFlags.BoolVar(&testUpdateGolden, "update-golden", false, "Rewrite the golden files of the test cases with the generated output instead of comparing them")
+}
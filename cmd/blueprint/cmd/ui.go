@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Fill in the parameters of a blueprint in a web browser",
+	Long: `Start a local web page with a form built from the parameters of the composed blueprint: Select parameters are
+dropdowns, Confirm parameters are checkboxes, secrets are password fields and File parameters are uploads. Fields are
+shown and hidden according to their promptIf conditions and checked with their validate expressions while the form is
+filled in. Submitting the form generates the blueprint in the chosen directory.`,
+	Example: `  xl-blueprint ui -b aws/monolith
+  xl-blueprint ui -l ./blueprints -b my-app --listen localhost:9000 --output-dir ./projects/my-app`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if uiBlueprintPath == "" {
+			util.Fatal("A blueprint path is required, use --blueprint\n")
+		}
+		context := buildContext()
+		DoUI(getBlueprintContext(context, uiLocalRepoPath), uiBlueprintPath, uiListenAddress, uiOutputDir)
+	},
+}
+
+var uiLocalRepoPath string
+var uiBlueprintPath string
+var uiListenAddress string
+var uiOutputDir string
+
+// DoUI serves the form of a blueprint until the process is stopped
+func DoUI(blueprintContext *blueprint.BlueprintContext, blueprintPath string, listenAddress string, outputDir string) {
+	repoContext, blueprintPath, err := blueprintContext.ResolveBlueprintPath(blueprintPath)
+	if err != nil {
+		util.Fatal("Error while reading blueprint: %s\n", err)
+	}
+	ui, err := blueprint.NewBlueprintUI(repoContext, blueprintPath, outputDir)
+	if err != nil {
+		util.Fatal("Error while reading blueprint: %s\n", err)
+	}
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		util.Fatal("Error while starting the web page: %s\n", err)
+	}
+	// use util.Print so that the address is printed in quiet mode too
+	util.Print("Open http://%s in your browser to fill in blueprint %s, press Ctrl-C to stop\n", listener.Addr(), blueprintPath)
+	if err := http.Serve(listener, ui); err != nil {
+		util.Fatal("Error while serving the web page: %s\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+
+	uiFlags := uiCmd.Flags()
+	uiFlags.StringVarP(&uiBlueprintPath, "blueprint", "b", "", "Blueprint path to fill in, relative to the active repository")
+	uiFlags.StringVarP(&uiLocalRepoPath, "local-repo", "l", "", "Local repository directory to use (bypasses active repository)")
+	uiFlags.StringVar(&uiListenAddress, "listen", "localhost:8180", "Address of the web page, as host:port, use port 0 for any free port")
+	uiFlags.StringVar(&uiOutputDir, "output-dir", ".", "Directory to generate the blueprint files in, can be changed in the form")
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade a generated project to the current version of its blueprint",
+	Long: `Generate the blueprint of a project again, with the answers recorded in its blueprint manifest
+(xebialabs/.blueprint.yaml), and apply the changes to the project.
+Files that were not edited since they were generated are replaced, edited files are merged with the changes of the
+blueprint using the copy of the generated files kept in xebialabs/.blueprint. Overlapping changes are marked in the
+file with <<<<<<<, ======= and >>>>>>> lines, and must be resolved by hand. Edited files without a generated copy are
+kept as they are and the changes of the blueprint are shown instead.
+Questions added in the new version of the blueprint are asked.
+The command exits with a non-zero status when there are conflicts.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		context := buildContext()
+		generatedBlueprint := &blueprint.GeneratedBlueprint{
+			BaseDir:   upgradeOutputDir,
+			OutputDir: upgradeXebiaLabsFolder,
+			DryRun:    upgradeDryRun,
+		}
+		manifest, err := blueprint.ReadBlueprintManifest(generatedBlueprint.OutputPath(blueprint.GetManifestPath(upgradeXebiaLabsFolder)))
+		if err != nil {
+			util.Fatal("Error while upgrading blueprint: %s\n", err)
+		}
+
+		blueprintContext := getBlueprintContext(context, upgradeLocalRepoPath)
+		if upgradeLocalRepoPath == "" && manifest.Repository != "" {
+			if err := blueprintContext.UseRepository(manifest.Repository); err != nil {
+				util.Info("Repository '%s' of the blueprint manifest is not defined, using the current repository\n", manifest.Repository)
+			}
+		}
+		DoUpgrade(blueprintContext, generatedBlueprint, manifest)
+	},
+}
+
+var upgradeLocalRepoPath string
+var upgradeBlueprintPath string
+var upgradeOutputDir string
+var upgradeXebiaLabsFolder string
+var upgradeDryRun bool
+
+// DoUpgrade upgrades the generated project to the current version of its blueprint
+func DoUpgrade(blueprintContext *blueprint.BlueprintContext, generatedBlueprint *blueprint.GeneratedBlueprint, manifest *blueprint.BlueprintManifest) {
+	stopInterruptHandler := rollbackOnInterrupt(generatedBlueprint)
+	results, err := blueprint.UpgradeBlueprint(blueprintContext, generatedBlueprint, manifest, upgradeBlueprintPath)
+	stopInterruptHandler()
+	if err != nil {
+		if !generatedBlueprint.DryRun {
+			generatedBlueprint.Cleanup()
+		}
+		util.Fatal("Error while upgrading blueprint: %s\n", err)
+	}
+	if err := generatedBlueprint.Finish(); err != nil {
+		util.Fatal("Error while removing the backup of the project files: %s\n", err)
+	}
+
+	if generatedBlueprint.DryRun {
+		util.Print("\nDry run, no files were written. Project files:\n")
+	} else {
+		util.Print("\nProject files:\n")
+	}
+	conflicts := 0
+	for _, result := range results {
+		if result.Status == blueprint.UpgradeStatusConflict {
+			conflicts++
+		}
+		if result.Message != "" {
+			util.Print("  %-10s %s (%s)\n", result.Status, result.Path, result.Message)
+		} else {
+			util.Print("  %-10s %s\n", result.Status, result.Path)
+		}
+	}
+	for _, result := range results {
+		if result.Diff != "" {
+			util.Print("\nChanges of the blueprint not applied to %s:\n%s", result.Path, result.Diff)
+		}
+	}
+	if conflicts > 0 {
+		util.Error("%d file(s) have conflicts, resolve them before using the project\n", conflicts)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+
+	upgradeFlags := upgradeCmd.Flags()
+	upgradeFlags.StringVarP(&upgradeLocalRepoPath, "local-repo", "l", "", "Local repository directory to use (bypasses the repository of the manifest)")
+	upgradeFlags.StringVarP(&upgradeBlueprintPath, "blueprint", "b", "", "Blueprint path to use instead of the one of the manifest")
+	upgradeFlags.StringVar(&upgradeOutputDir, "output-dir", "", "Directory of the generated project, defaults to the current directory")
+	upgradeFlags.StringVar(&upgradeXebiaLabsFolder, "xebialabs-folder", models.BlueprintOutputDir, "Folder of the project containing the blueprint manifest")
+	upgradeFlags.BoolVar(&upgradeDryRun, "dry-run", false, "Show what would change without writing anything")
+}
@@ -0,0 +1,141 @@
+package blueprint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xebialabs/yaml"
+)
+
+// GenerateAnswersSkeleton returns a commented answers file with a key for every parameter of the composed blueprint
+// that can be asked, static default values are filled in and all other values are left empty
+func (blueprintContext *BlueprintContext) GenerateAnswersSkeleton(templatePath string) (string, error) {
+	blueprints, err := blueprintContext.initCurrentRepoClient()
+	if err != nil {
+		return "", err
+	}
+	blueprintDocs, _, err := getBlueprintConfig(blueprintContext, blueprints, templatePath, []VarField{{}}, "")
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Answers file for blueprint %s\n", templatePath)
+	sb.WriteString("# Use it with --answers, add --strict-answers to fail when an answer is missing\n")
+
+	names := make(map[string]bool)
+	for _, blueprintDoc := range blueprintDocs {
+		includeIf := getIncludeConditions(blueprintDoc)
+		for _, variable := range blueprintDoc.BlueprintConfig.Variables {
+			// parameters with a value are never asked, and the same answer is used for parameters defined more than once
+			if variable.Value.Value != "" || variable.Name.Value == "" || names[variable.Name.Value] {
+				continue
+			}
+			names[variable.Name.Value] = true
+
+			entry, err := answersSkeletonEntry(variable, blueprintDoc.Name, includeIf)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("\n")
+			sb.WriteString(entry)
+		}
+	}
+	return sb.String(), nil
+}
+
+func answersSkeletonEntry(variable Variable, blueprintName string, includeIf []string) (string, error) {
+	var sb strings.Builder
+	if prompt := strings.TrimSpace(variable.Prompt.Value); prompt != "" {
+		writeComment(&sb, prompt)
+	}
+	if description := strings.TrimSpace(variable.Description.Value); description != "" {
+		writeComment(&sb, description)
+	}
+
+	details := []string{"type: " + variable.Type.Value}
+	if IsSecretType(variable.Type.Value) {
+		details = append(details, "secret, saved in secrets.xlvals")
+	} else if variable.SaveInXlvals.Bool {
+		details = append(details, "saved in values.xlvals")
+	}
+	writeComment(&sb, strings.Join(details, ", "))
+
+	if len(variable.Options) > 0 {
+		var options []string
+		for _, option := range variable.Options {
+			if option.Tag != "" {
+				options = append(options, formatVarField(option))
+			} else {
+				options = append(options, getOptionTextWithLabel(option))
+			}
+		}
+		writeComment(&sb, "options: "+strings.Join(options, ", "))
+	}
+	if defaultVal := formatVarField(variable.Default); defaultVal != "" {
+		writeComment(&sb, "default: "+defaultVal)
+	}
+	if validate := formatVarField(variable.Validate); validate != "" {
+		writeComment(&sb, "validate: "+validate)
+	}
+	if condition := joinConditions(includeIf, formatVarField(variable.DependsOn)); condition != "" {
+		writeComment(&sb, "only asked when: "+condition)
+	}
+	writeComment(&sb, "blueprint: "+blueprintName)
+
+	// static defaults are a good starting point, values calculated from expressions are only known while generating
+	answer := ""
+	if variable.Default.Tag == "" {
+		answer = variable.Default.Value
+	}
+	content, err := yaml.Marshal(yaml.MapSlice{{Key: variable.Name.Value, Value: answer}})
+	if err != nil {
+		return "", err
+	}
+	sb.Write(content)
+	return sb.String(), nil
+}
+
+// FormatAnswers returns the answers of a generated blueprint, including the answers of its included blueprints, as an
+// answers file that generates the same project again. Secret answers are not saved and file answers only hold the
+// file contents, both are written as empty answers with a comment.
+func FormatAnswers(templatePath string, blueprintDoc *BlueprintConfig, preparedData *PreparedData) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Answers of blueprint %s\n", templatePath)
+	sb.WriteString("# Use it with --answers and --strict-answers to generate the project again without questions\n")
+
+	names := make(map[string]bool)
+	for _, variable := range blueprintDoc.Variables {
+		if variable.Value.Value != "" || variable.Name.Value == "" || names[variable.Name.Value] {
+			continue
+		}
+		value, ok := preparedData.TemplateData[variable.Name.Value]
+		if !ok || value == nil {
+			continue
+		}
+		names[variable.Name.Value] = true
+
+		answer := fmt.Sprint(value)
+		switch {
+		case IsSecretType(variable.Type.Value):
+			writeComment(&sb, fmt.Sprintf("secret, not saved, give it here or with --set %s=... or --env-answers", variable.Name.Value))
+			answer = ""
+		case variable.Type.Value == TypeFile:
+			writeComment(&sb, "file path, not saved")
+			answer = ""
+		}
+		content, err := yaml.Marshal(yaml.MapSlice{{Key: variable.Name.Value, Value: answer}})
+		if err != nil {
+			return "", err
+		}
+		sb.Write(content)
+	}
+	return sb.String(), nil
+}
+
+func writeComment(sb *strings.Builder, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString(strings.TrimRight("# "+line, " "))
+		sb.WriteString("\n")
+	}
+}
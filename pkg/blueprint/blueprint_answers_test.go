@@ -0,0 +1,152 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprintContext_GenerateAnswersSkeleton(t *testing.T) {
+	t.Run("should generate a commented key for every promptable parameter", func(t *testing.T) {
+		skeleton, err := getLocalTestBlueprintContext(t).GenerateAnswersSkeleton("answer-input")
+		require.Nil(t, err)
+
+		assert.Contains(t, skeleton, "# Answers file for blueprint answer-input\n")
+		assert.Contains(t, skeleton, `
+# Test Prompt
+# Application name, will be used in various AWS resource names
+# type: Input, saved in values.xlvals
+# validate: !expr regex('[a-zA-Z-]*', AppName)
+# blueprint: answer-input
+AppName: ""
+`)
+		assert.Contains(t, skeleton, `
+# Test Prompt
+# type: Input, saved in values.xlvals
+# only asked when: TestDepends2
+# blueprint: answer-input
+ShouldNotBeThere: ""
+`)
+		assert.Contains(t, skeleton, `
+# Test Prompt
+# type: SecretInput, secret, saved in secrets.xlvals
+# blueprint: answer-input
+AWSAccessKey: ""
+`)
+		assert.Contains(t, skeleton, `
+# Test Prompt
+# type: Select, saved in values.xlvals
+# options: !expr awsRegions('ecs')
+# default: !expr awsRegions('ecs', 0)
+# blueprint: answer-input
+AWSRegion: ""
+`)
+	})
+
+	t.Run("should skip parameters with values and fill in static defaults", func(t *testing.T) {
+		skeleton, err := getLocalTestBlueprintContext(t).GenerateAnswersSkeleton("composed")
+		require.Nil(t, err)
+
+		assert.NotContains(t, skeleton, "TestFoo:")
+		assert.NotContains(t, skeleton, "\nTest:")
+		assert.Contains(t, skeleton, `
+# Test Prompt
+# type: Confirm
+# default: true
+# blueprint: defaults-as-values
+TestDepends: "true"
+`)
+	})
+
+	t.Run("should produce a file accepted as answers file", func(t *testing.T) {
+		skeleton, err := getLocalTestBlueprintContext(t).GenerateAnswersSkeleton("defaults-as-values")
+		require.Nil(t, err)
+
+		dir, err := ioutil.TempDir("", "answersskeleton")
+		require.Nil(t, err)
+		defer os.RemoveAll(dir)
+		answersFile := filepath.Join(dir, "answers.yaml")
+		require.Nil(t, ioutil.WriteFile(answersFile, []byte(skeleton), 0644))
+
+		answers, err := GetValuesFromAnswersFile(answersFile)
+		require.Nil(t, err)
+		assert.Equal(t, "testing", answers["Test"])
+		assert.Equal(t, "true", answers["TestDepends"])
+		assert.Contains(t, answers, "AppName")
+	})
+}
+
+func TestFormatAnswers(t *testing.T) {
+	SkipFinalPrompt = true
+	dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"repo/app/blueprint.yaml": `apiVersion: xl/v2
+kind: Blueprint
+spec:
+  parameters:
+  - name: AppName
+    type: Input
+    prompt: Name?
+  - name: Static
+    value: static
+  - name: Password
+    type: SecretInput
+    prompt: Password?
+  - name: UseDb
+    type: Confirm
+    prompt: Database?
+  files:
+  - path: app.yaml.tmpl
+  includeAfter:
+  - blueprint: db
+    includeIf: !expr UseDb
+`,
+		"repo/app/app.yaml.tmpl": "{{.AppName}} {{.Static}} {{.Size}}",
+		"repo/db/blueprint.yaml": `apiVersion: xl/v2
+kind: Blueprint
+spec:
+  parameters:
+  - name: Size
+    type: Select
+    prompt: Size?
+    options: [small, large]
+  files:
+  - path: db.yaml
+`,
+		"repo/db/db.yaml": "db",
+	})
+	defer cleanup()
+	blueprintContext, err := ConstructLocalBlueprintContext(filepath.Join(dir, "repo"))
+	require.Nil(t, err)
+
+	generate := func(outputDir string, params BlueprintParams) (*PreparedData, *BlueprintConfig) {
+		params.TemplatePath = "app"
+		params.StrictAnswers = true
+		gb := &GeneratedBlueprint{BaseDir: filepath.Join(dir, outputDir), OutputDir: "xebialabs"}
+		preparedData, blueprintDoc, err := InstantiateBlueprint(params, blueprintContext, gb, nil)
+		require.Nil(t, err)
+		return preparedData, blueprintDoc
+	}
+	preparedData, blueprintDoc := generate("first", BlueprintParams{
+		AnswersMap: map[string]string{"AppName": "demo", "Password": "s3cret", "UseDb": "true", "Size": "large"},
+	})
+
+	answers, err := FormatAnswers("app", blueprintDoc, preparedData)
+	require.Nil(t, err)
+	assert.Equal(t, "# Answers of blueprint app\n"+
+		"# Use it with --answers and --strict-answers to generate the project again without questions\n"+
+		"AppName: demo\n"+
+		"# secret, not saved, give it here or with --set Password=... or --env-answers\n"+
+		"Password: \"\"\n"+
+		"UseDb: \"true\"\n"+
+		"Size: large\n", answers)
+
+	answersFile := filepath.Join(dir, "answers.yaml")
+	require.Nil(t, ioutil.WriteFile(answersFile, []byte(answers), 0640))
+	generate("second", BlueprintParams{AnswersFile: answersFile, AnswersMap: map[string]string{"Password": "s3cret"}})
+	assert.Equal(t, GetFileContent(filepath.Join(dir, "first", "app.yaml")), GetFileContent(filepath.Join(dir, "second", "app.yaml")))
+	assert.Equal(t, "db", GetFileContent(filepath.Join(dir, "second", "db.yaml")))
+}
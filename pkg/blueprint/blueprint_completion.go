@@ -0,0 +1,64 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"github.com/xebialabs/yaml"
+)
+
+// CompletionCacheMaxAge is how long the blueprint paths of a remote repository are cached for shell completion
+const CompletionCacheMaxAge = 5 * time.Minute
+
+type completionCacheEntry struct {
+	Provider string   `yaml:"provider"`
+	Updated  int64    `yaml:"updated"`
+	Paths    []string `yaml:"paths"`
+}
+
+// ListBlueprintPaths returns the sorted paths of the blueprints in the active repository.
+// The paths of remote repositories are cached in cachePath, completing a flag should not wait for the repository on
+// every key press. Local repositories are always read.
+func (blueprintContext *BlueprintContext) ListBlueprintPaths(cachePath string, now time.Time) ([]string, error) {
+	repo := *blueprintContext.ActiveRepo
+	useCache := cachePath != "" && repo.GetProvider() != models.ProviderLocal
+
+	cache := make(map[string]completionCacheEntry)
+	if useCache {
+		if content, err := ioutil.ReadFile(cachePath); err == nil {
+			if err := yaml.Unmarshal(content, &cache); err != nil {
+				util.Verbose("[completion] Ignoring invalid cache file %s: %s\n", cachePath, err)
+				cache = make(map[string]completionCacheEntry)
+			}
+		}
+		entry, ok := cache[repo.GetName()]
+		if ok && entry.Provider == repo.GetProvider() && now.Sub(time.Unix(entry.Updated, 0)) < CompletionCacheMaxAge {
+			return entry.Paths, nil
+		}
+	}
+
+	blueprints, err := blueprintContext.initCurrentRepoClient()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(blueprints))
+	for path := range blueprints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if useCache {
+		cache[repo.GetName()] = completionCacheEntry{Provider: repo.GetProvider(), Updated: now.Unix(), Paths: paths}
+		content, err := yaml.Marshal(cache)
+		if err == nil {
+			err = ioutil.WriteFile(cachePath, content, 0640)
+		}
+		if err != nil {
+			util.Verbose("[completion] Cannot write cache file %s: %s\n", cachePath, err)
+		}
+	}
+	return paths, nil
+}
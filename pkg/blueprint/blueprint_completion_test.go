@@ -0,0 +1,62 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/mock"
+	"github.com/xebialabs/yaml"
+)
+
+func TestListBlueprintPaths(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "completionTest")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+	cachePath := filepath.Join(tmpDir, "cache.yaml")
+
+	mockRepo, err := mock.NewMockBlueprintRepository(map[string]string{"name": "Mock"})
+	require.Nil(t, err)
+	var repo repository.BlueprintRepository = mockRepo
+	blueprintContext := &BlueprintContext{ActiveRepo: &repo}
+	now := time.Unix(1500000000, 0)
+
+	t.Run("should list and cache the blueprint paths", func(t *testing.T) {
+		paths, err := blueprintContext.ListBlueprintPaths(cachePath, now)
+		require.Nil(t, err)
+		assert.Equal(t, []string{"xl/test"}, paths)
+
+		cache := make(map[string]completionCacheEntry)
+		require.Nil(t, yaml.Unmarshal([]byte(GetFileContent(cachePath)), &cache))
+		assert.Equal(t, completionCacheEntry{Provider: "mock", Updated: now.Unix(), Paths: []string{"xl/test"}}, cache["Mock"])
+	})
+
+	t.Run("should use the cache until it expires", func(t *testing.T) {
+		content, err := yaml.Marshal(map[string]completionCacheEntry{
+			"Mock": {Provider: "mock", Updated: now.Unix(), Paths: []string{"cached/path"}},
+		})
+		require.Nil(t, err)
+		require.Nil(t, ioutil.WriteFile(cachePath, content, 0640))
+
+		paths, err := blueprintContext.ListBlueprintPaths(cachePath, now.Add(time.Minute))
+		require.Nil(t, err)
+		assert.Equal(t, []string{"cached/path"}, paths)
+
+		paths, err = blueprintContext.ListBlueprintPaths(cachePath, now.Add(CompletionCacheMaxAge))
+		require.Nil(t, err)
+		assert.Equal(t, []string{"xl/test"}, paths)
+	})
+
+	t.Run("should not cache local repositories", func(t *testing.T) {
+		localCachePath := filepath.Join(tmpDir, "local.yaml")
+		paths, err := getLocalTestBlueprintContext(t).ListBlueprintPaths(localCachePath, now)
+		require.Nil(t, err)
+		assert.Contains(t, paths, "answer-input")
+		assert.False(t, exists(localCachePath))
+	})
+}
@@ -0,0 +1,176 @@
+package blueprint
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+)
+
+// policies for generated files that already exist with a different content
+const (
+	ConflictPolicyPrompt    = "prompt"
+	ConflictPolicyOverwrite = "overwrite"
+	ConflictPolicySkip      = "skip"
+	ConflictPolicyKeepBoth  = "keep-both"
+	ConflictPolicyDiff      = "diff"
+	ConflictPolicyFail      = "fail"
+
+	keepBothSuffix = ".new"
+)
+
+// ConflictPolicies are the supported values for the conflict policy
+var ConflictPolicies = []string{
+	ConflictPolicyPrompt,
+	ConflictPolicyOverwrite,
+	ConflictPolicySkip,
+	ConflictPolicyKeepBoth,
+	ConflictPolicyDiff,
+	ConflictPolicyFail,
+}
+
+const (
+	conflictOptionOverwrite = "Overwrite the existing file"
+	conflictOptionSkip      = "Skip, keep the existing file"
+	conflictOptionKeepBoth  = "Keep both, write the generated file with a %s suffix"
+	conflictOptionDiff      = "Show the differences"
+)
+
+// getConflictPolicy validates the conflict policy, when it is not set existing files are only overwritten when the
+// user confirms it, and non-interactive runs fail instead
+func getConflictPolicy(policy string, strictAnswers bool) (string, error) {
+	if policy == "" {
+		if strictAnswers || !isInteractive() {
+			return ConflictPolicyFail, nil
+		}
+		return ConflictPolicyPrompt, nil
+	}
+	if !util.IsStringInSlice(policy, ConflictPolicies) {
+		return "", fmt.Errorf("conflict policy [%s] is not valid, it must be one of %s", policy, strings.Join(ConflictPolicies, ", "))
+	}
+	return policy, nil
+}
+
+// isInteractive checks if the standard input is a terminal
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// resolveConflict applies the conflict policy when the output file exists with a different content, it returns the
+// name of the file to write, or false when the existing file should be kept
+func (generatedBlueprint *GeneratedBlueprint) resolveConflict(fileName string, data string) (string, bool, error) {
+	policy := generatedBlueprint.conflictPolicy
+	if generatedBlueprint.DryRun || policy == "" || policy == ConflictPolicyOverwrite {
+		return fileName, true, nil
+	}
+	filePath := generatedBlueprint.OutputPath(fileName)
+	existing, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return fileName, true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if string(existing) == data {
+		return fileName, true, nil
+	}
+
+	if policy == ConflictPolicyDiff {
+		printConflictDiff(fileName, string(existing), data, generatedBlueprint.surveyOpts...)
+		policy = ConflictPolicyPrompt
+	}
+	if policy == ConflictPolicyPrompt {
+		policy, err = askConflictPolicy(fileName, string(existing), data, generatedBlueprint.surveyOpts...)
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	switch policy {
+	case ConflictPolicyOverwrite:
+		return fileName, true, nil
+	case ConflictPolicySkip:
+		util.Info("[file] Keeping existing file '%s'\n", filePath)
+		return fileName, false, nil
+	case ConflictPolicyKeepBoth:
+		return fileName + keepBothSuffix, true, nil
+	default:
+		return "", false, fmt.Errorf("file %s already exists, set the conflict policy (--on-conflict) to overwrite, skip or keep both files", filePath)
+	}
+}
+
+func askConflictPolicy(fileName string, existing string, data string, surveyOpts ...survey.AskOpt) (string, error) {
+	keepBoth := fmt.Sprintf(conflictOptionKeepBoth, keepBothSuffix)
+	for {
+		var answer string
+		err := survey.AskOne(
+			&survey.Select{
+				Message: fmt.Sprintf("File %s already exists, what do you want to do?", fileName),
+				Options: []string{conflictOptionOverwrite, conflictOptionSkip, keepBoth, conflictOptionDiff},
+			},
+			&answer,
+			nil,
+			surveyOpts...,
+		)
+		if err != nil {
+			return "", err
+		}
+		switch answer {
+		case conflictOptionOverwrite:
+			return ConflictPolicyOverwrite, nil
+		case conflictOptionSkip:
+			return ConflictPolicySkip, nil
+		case keepBoth:
+			return ConflictPolicyKeepBoth, nil
+		default:
+			printConflictDiff(fileName, existing, data, surveyOpts...)
+		}
+	}
+}
+
+// printConflictDiff shows the differences between the existing and the generated file next to the questions, so that
+// they do not end up in the JSON output of the command
+func printConflictDiff(fileName string, existing string, data string, surveyOpts ...survey.AskOpt) {
+	fmt.Fprint(surveyOutput(surveyOpts...), util.UnifiedDiff("a/"+fileName, "b/"+fileName, existing, data, 3))
+}
+
+// surveyOutput returns the stream the questions are written to
+func surveyOutput(surveyOpts ...survey.AskOpt) io.Writer {
+	options := survey.AskOptions{}
+	for _, opt := range surveyOpts {
+		if err := opt(&options); err != nil {
+			break
+		}
+	}
+	if options.Stdio.Out == nil {
+		return os.Stdout
+	}
+	return options.Stdio.Out
+}
+
+// mergeGitignore adds the generated entries that are missing to an existing .gitignore file
+func (generatedBlueprint *GeneratedBlueprint) mergeGitignore(fileName string, data string) (string, error) {
+	existing, err := ioutil.ReadFile(generatedBlueprint.OutputPath(fileName))
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	content := string(existing)
+	for _, entry := range util.SplitLines(data) {
+		if util.IsStringInSlice(entry, util.SplitLines(content)) {
+			continue
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += entry + "\n"
+	}
+	return content, nil
+}
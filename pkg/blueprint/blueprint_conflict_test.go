@@ -0,0 +1,176 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+)
+
+func TestGetConflictPolicy(t *testing.T) {
+	policy, err := getConflictPolicy("", true)
+	require.Nil(t, err)
+	assert.Equal(t, ConflictPolicyFail, policy)
+
+	policy, err = getConflictPolicy(ConflictPolicyKeepBoth, true)
+	require.Nil(t, err)
+	assert.Equal(t, ConflictPolicyKeepBoth, policy)
+
+	_, err = getConflictPolicy("replace", false)
+	require.NotNil(t, err)
+	assert.Equal(t, "conflict policy [replace] is not valid, it must be one of prompt, overwrite, skip, keep-both, diff, fail", err.Error())
+}
+
+func TestGeneratedBlueprint_resolveConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conflictTest")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("existing"), 0644))
+
+	tests := []struct {
+		name     string
+		policy   string
+		fileName string
+		data     string
+		want     string
+		write    bool
+		wantErr  bool
+	}{
+		{"should write new files", ConflictPolicyFail, "new.yaml", "generated", "new.yaml", true, false},
+		{"should write files with the same content", ConflictPolicyFail, "app.yaml", "existing", "app.yaml", true, false},
+		{"should overwrite when no policy is set", "", "app.yaml", "generated", "app.yaml", true, false},
+		{"should overwrite", ConflictPolicyOverwrite, "app.yaml", "generated", "app.yaml", true, false},
+		{"should keep the existing file", ConflictPolicySkip, "app.yaml", "generated", "app.yaml", false, false},
+		{"should keep both files", ConflictPolicyKeepBoth, "app.yaml", "generated", "app.yaml.new", true, false},
+		{"should fail", ConflictPolicyFail, "app.yaml", "generated", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gb := &GeneratedBlueprint{BaseDir: dir, conflictPolicy: tt.policy}
+			got, write, err := gb.resolveConflict(tt.fileName, tt.data)
+			if tt.wantErr {
+				require.NotNil(t, err)
+				assert.Contains(t, err.Error(), "app.yaml already exists")
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.write, write)
+		})
+	}
+
+	t.Run("should not resolve conflicts in dry-run mode", func(t *testing.T) {
+		gb := &GeneratedBlueprint{BaseDir: dir, conflictPolicy: ConflictPolicyFail, DryRun: true}
+		got, write, err := gb.resolveConflict("app.yaml", "generated")
+		require.Nil(t, err)
+		assert.Equal(t, "app.yaml", got)
+		assert.True(t, write)
+	})
+}
+
+func TestSurveyOutput(t *testing.T) {
+	assert.Equal(t, os.Stdout, surveyOutput())
+	assert.Equal(t, os.Stderr, surveyOutput(survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)))
+}
+
+func TestGeneratedBlueprint_mergeGitignore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitignoreTest")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	gb := &GeneratedBlueprint{BaseDir: dir}
+
+	content, err := gb.mergeGitignore(gitignoreFile, secretsFile)
+	require.Nil(t, err)
+	assert.Equal(t, secretsFile, content)
+
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, gitignoreFile), []byte("node_modules"), 0644))
+	content, err = gb.mergeGitignore(gitignoreFile, secretsFile)
+	require.Nil(t, err)
+	assert.Equal(t, "node_modules\nsecrets.xlvals\n", content)
+
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, gitignoreFile), []byte("secrets.xlvals\nnode_modules\n"), 0644))
+	content, err = gb.mergeGitignore(gitignoreFile, secretsFile)
+	require.Nil(t, err)
+	assert.Equal(t, "secrets.xlvals\nnode_modules\n", content)
+}
+
+func TestInstantiateBlueprintWithExistingFiles(t *testing.T) {
+	repoDir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"app/blueprint.yaml": `
+apiVersion: xl/v2
+kind: Blueprint
+spec:
+  parameters:
+  - name: AppName
+    value: my-app
+  files:
+  - path: app.yaml.tmpl
+  - path: README.md
+`,
+		"app/app.yaml.tmpl": "name: {{.AppName}}",
+		"app/README.md":     "generated readme",
+	})
+	defer cleanup()
+	blueprintContext, err := ConstructLocalBlueprintContext(repoDir)
+	require.Nil(t, err)
+
+	projectDir := filepath.Join(repoDir, "project")
+	writeExisting := func() {
+		require.Nil(t, os.MkdirAll(filepath.Join(projectDir, "xebialabs"), 0755))
+		require.Nil(t, ioutil.WriteFile(filepath.Join(projectDir, "README.md"), []byte("my readme"), 0644))
+		require.Nil(t, ioutil.WriteFile(filepath.Join(projectDir, "xebialabs", gitignoreFile), []byte("*.log\n"), 0644))
+	}
+	instantiate := func(onConflict string) (*GeneratedBlueprint, error) {
+		gb := &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs"}
+		_, _, err := InstantiateBlueprint(
+			BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{}, StrictAnswers: true, OnConflict: onConflict},
+			blueprintContext, gb, nil,
+		)
+		if err == nil {
+			err = gb.Finish()
+		}
+		return gb, err
+	}
+
+	t.Run("should fail and restore the existing files in non-interactive runs", func(t *testing.T) {
+		writeExisting()
+		defer os.RemoveAll(projectDir)
+
+		gb, err := instantiate("")
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "README.md already exists")
+		require.Nil(t, gb.Cleanup())
+
+		assert.Equal(t, "my readme", GetFileContent(filepath.Join(projectDir, "README.md")))
+		assert.Equal(t, "*.log\n", GetFileContent(filepath.Join(projectDir, "xebialabs", gitignoreFile)))
+		_, err = os.Stat(filepath.Join(projectDir, "app.yaml"))
+		assert.True(t, os.IsNotExist(err))
+		assert.Empty(t, stagingDirs(t, projectDir))
+	})
+
+	t.Run("should keep existing files", func(t *testing.T) {
+		writeExisting()
+		defer os.RemoveAll(projectDir)
+
+		gb, err := instantiate(ConflictPolicySkip)
+		require.Nil(t, err)
+		assert.Equal(t, "my readme", GetFileContent(filepath.Join(projectDir, "README.md")))
+		assert.Equal(t, "name: my-app", GetFileContent(filepath.Join(projectDir, "app.yaml")))
+		assert.Contains(t, gb.Files, FileRecord{Path: "README.md", Status: FileStatusKept, Size: 16, Reason: "the existing file is kept", Content: "generated readme"})
+	})
+
+	t.Run("should write the generated file next to the existing file", func(t *testing.T) {
+		writeExisting()
+		defer os.RemoveAll(projectDir)
+
+		_, err := instantiate(ConflictPolicyKeepBoth)
+		require.Nil(t, err)
+		assert.Equal(t, "my readme", GetFileContent(filepath.Join(projectDir, "README.md")))
+		assert.Equal(t, "generated readme", GetFileContent(filepath.Join(projectDir, "README.md.new")))
+		assert.Empty(t, stagingDirs(t, projectDir))
+	})
+}
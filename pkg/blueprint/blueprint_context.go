@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
 	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/bitbucket"
 	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/bitbucketserver"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/cache"
 	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/github"
 	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/gitlab"
 	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/http"
@@ -35,6 +37,10 @@ const (
 
 	FlagBlueprintCurrentRepository     = ContextPrefix + "-current-repository"
 	ViperKeyBlueprintCurrentRepository = ContextPrefix + ".current-repository"
+	FlagBlueprintOffline               = "offline"
+	ViperKeyBlueprintOffline           = ContextPrefix + ".offline"
+
+	repositoryCacheDir = "blueprint-cache"
 )
 
 // BlueprintContext holds necessary remote/local repository information for connection
@@ -73,13 +79,8 @@ func GetDefaultBlueprintViperConfig(v *viper.Viper, configPath string) (*viper.V
 	// existing config file on disk
 	var vFromConfig *viper.Viper
 	if WriteConfigFile && util.PathExists(configPath, false) {
-		vFromConfig = viper.New()
-		vFromConfig.SetConfigType("yaml")
-		bytesread, err := ioutil.ReadFile(configPath)
-		if err != nil {
-			return v, nil, "", err
-		}
-		err = vFromConfig.ReadConfig(bytes.NewBuffer(bytesread))
+		var err error
+		vFromConfig, err = readConfigFile(configPath)
 		if err != nil {
 			return v, nil, "", err
 		}
@@ -117,12 +118,7 @@ func CreateOrUpdateBlueprintConfig(v *viper.Viper, configPath string) (*viper.Vi
 	}
 	if WriteConfigFile && vFromConfig != nil {
 		// write to existing config file
-		c := util.SortMapStringInterface(vFromConfig.AllSettings())
-		yamlBytes, err := yaml.Marshal(c)
-		if err != nil {
-			return v, activeRepoName, err
-		}
-		err = ioutil.WriteFile(configPath, yamlBytes, 0640)
+		err = writeConfigFile(vFromConfig, configPath)
 		if err != nil {
 			return v, activeRepoName, err
 		}
@@ -130,10 +126,40 @@ func CreateOrUpdateBlueprintConfig(v *viper.Viper, configPath string) (*viper.Vi
 	return v, activeRepoName, nil
 }
 
+func readConfigFile(configPath string) (*viper.Viper, error) {
+	vFromConfig := viper.New()
+	vFromConfig.SetConfigType("yaml")
+	bytesread, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	err = vFromConfig.ReadConfig(bytes.NewBuffer(bytesread))
+	if err != nil {
+		return nil, err
+	}
+	return vFromConfig, nil
+}
+
+func writeConfigFile(v *viper.Viper, configPath string) error {
+	c := util.SortMapStringInterface(v.AllSettings())
+	yamlBytes, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, yamlBytes, 0640)
+}
+
 func SetRootFlags(rootFlags *pflag.FlagSet) {
 	rootFlags.String(FlagBlueprintCurrentRepository, "", "Current active blueprint repository name")
+	rootFlags.Bool(FlagBlueprintOffline, false, "Use only the cached content of remote blueprint repositories, without network access")
 
 	viper.BindPFlag(ViperKeyBlueprintCurrentRepository, rootFlags.Lookup(FlagBlueprintCurrentRepository))
+	viper.BindPFlag(ViperKeyBlueprintOffline, rootFlags.Lookup(FlagBlueprintOffline))
+}
+
+// GetRepositoryCacheDir returns the directory the content of remote repositories is cached in, next to the config file
+func GetRepositoryCacheDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), repositoryCacheDir)
 }
 
 func ConstructLocalBlueprintContext(localRepoPath string) (*BlueprintContext, error) {
@@ -170,6 +196,7 @@ func ConstructBlueprintContext(v *viper.Viper, configPath, CLIVersion string) (*
 
 	var currentRepo *repository.BlueprintRepository
 	var definedRepos []*repository.BlueprintRepository
+	offline := v.GetBool(ViperKeyBlueprintOffline)
 
 	repoDefinitions := make([]ConfMap, 1, 1)
 	err = v.UnmarshalKey(RepositoryConfigKey, &repoDefinitions)
@@ -183,34 +210,13 @@ func ConstructBlueprintContext(v *viper.Viper, configPath, CLIVersion string) (*
 			return nil, fmt.Errorf("repository with index %d doesn't have all mandatory fields set [type, name]", i)
 		}
 
-		// Get repository type
-		var repo repository.BlueprintRepository
-		repoProvider, err := models.GetRepoProvider(repoDefinition["type"])
+		repo, err := NewBlueprintRepository(repoDefinition, CLIVersion)
 		if err != nil {
 			return nil, err
 		}
-		util.Verbose("Creating blueprint repo configuration %v\n", repoDefinition)
-		// Parse according to type string
-		switch repoProvider {
-		case models.ProviderMock: // only used for testing purposes
-			repo, err = mock.NewMockBlueprintRepository(repoDefinition)
-		case models.ProviderLocal:
-			repo, err = local.NewLocalBlueprintRepository(repoDefinition)
-		case models.ProviderGitHub:
-			repo, err = github.NewGitHubBlueprintRepository(repoDefinition)
-		case models.ProviderBitbucket:
-			repo, err = bitbucket.NewBitbucketBlueprintRepository(repoDefinition)
-		case models.ProviderBitbucketServer:
-			repo, err = bitbucketserver.NewBitbucketServerBlueprintRepository(repoDefinition)
-		case models.ProviderHttp:
-			repo, err = http.NewHttpBlueprintRepository(repoDefinition, CLIVersion)
-		case models.ProviderGitLab:
-			repo, err = gitlab.NewGitLabBlueprintRepository(repoDefinition)
-		default:
-			return nil, fmt.Errorf("no blueprint provider implementation found for %s", repoProvider)
-		}
-		if err != nil {
-			return nil, err
+		// remote repositories are cached, so that they can be used offline
+		if _, ok := repo.(repository.RevisionedRepository); ok {
+			repo = cache.NewCachedBlueprintRepository(repo, GetRepositoryCacheDir(configPath), offline)
 		}
 		definedRepos = append(definedRepos, &repo)
 
@@ -231,6 +237,40 @@ func ConstructBlueprintContext(v *viper.Viper, configPath, CLIVersion string) (*
 	}, nil
 }
 
+// NewBlueprintRepository creates the repository client for a single repository definition, based on its type
+func NewBlueprintRepository(repoDefinition ConfMap, CLIVersion string) (repository.BlueprintRepository, error) {
+	// Get repository type
+	var repo repository.BlueprintRepository
+	repoProvider, err := models.GetRepoProvider(repoDefinition["type"])
+	if err != nil {
+		return nil, err
+	}
+	util.Verbose("Creating blueprint repo configuration %v\n", repoDefinition)
+	// Parse according to type string
+	switch repoProvider {
+	case models.ProviderMock: // only used for testing purposes
+		repo, err = mock.NewMockBlueprintRepository(repoDefinition)
+	case models.ProviderLocal:
+		repo, err = local.NewLocalBlueprintRepository(repoDefinition)
+	case models.ProviderGitHub:
+		repo, err = github.NewGitHubBlueprintRepository(repoDefinition)
+	case models.ProviderBitbucket:
+		repo, err = bitbucket.NewBitbucketBlueprintRepository(repoDefinition)
+	case models.ProviderBitbucketServer:
+		repo, err = bitbucketserver.NewBitbucketServerBlueprintRepository(repoDefinition)
+	case models.ProviderHttp:
+		repo, err = http.NewHttpBlueprintRepository(repoDefinition, CLIVersion)
+	case models.ProviderGitLab:
+		repo, err = gitlab.NewGitLabBlueprintRepository(repoDefinition)
+	default:
+		return nil, fmt.Errorf("no blueprint provider implementation found for %s", repoProvider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
 func (blueprintContext *BlueprintContext) initCurrentRepoClient() (map[string]*models.BlueprintRemote, error) {
 	err := (*blueprintContext.ActiveRepo).Initialize()
 	if err != nil {
@@ -0,0 +1,660 @@
+package blueprint
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xebialabs/blueprint-cli/pkg/cloud/aws"
+	"github.com/xebialabs/blueprint-cli/pkg/cloud/k8s"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/yaml"
+)
+
+// BlueprintConversion is the result of converting an xl/v1 blueprint definition to xl/v2
+type BlueprintConversion struct {
+	Content  []byte
+	Warnings []string
+}
+
+var convertParameterFields = map[string]string{
+	"description":        "prompt",
+	"saveInXlVals":       "saveInXlvals",
+	"useRawValue":        "replaceAsIs",
+	"showValueOnSummary": "revealOnSummary",
+}
+
+var regExIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var regExYamlKeyLine = regexp.MustCompile(`^([A-Za-z0-9_.\-]+):(?:\s+(.*))?$`)
+
+// ConvertBlueprintV1 rewrites an xl/v1 blueprint definition as an xl/v2 definition.
+// Comments and the text of unchanged values are kept, everything that could not be converted automatically is
+// returned as a warning.
+func ConvertBlueprintV1(content []byte) (*BlueprintConversion, error) {
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if apiVersion := fmt.Sprint(getMapSliceValue(doc, "apiVersion")); apiVersion != models.BlueprintYamlFormatV1 {
+		return nil, fmt.Errorf("apiVersion [%s] is not supported, only %s blueprints can be converted", apiVersion, models.BlueprintYamlFormatV1)
+	}
+
+	sources, trailingComments := scanYamlSources(string(content))
+	converter := &blueprintConverter{sources: sources, origins: make(map[string][]string)}
+	converted := converter.convertDocument(doc)
+
+	emitter := &yamlEmitter{sources: sources, origins: converter.origins}
+	emitter.writeMap(converted, 0, "", "")
+	for _, comment := range trailingComments {
+		emitter.buf.WriteString(comment + "\n")
+	}
+
+	result := emitter.buf.Bytes()
+	if _, err := parseTemplateMetadataV2(&result, "", nil); err != nil {
+		converter.warn("the converted blueprint is not valid, please fix it by hand: %s", err)
+	}
+	return &BlueprintConversion{Content: result, Warnings: converter.warnings}, nil
+}
+
+type blueprintConverter struct {
+	sources  map[string]*yamlSource
+	origins  map[string][]string
+	warnings []string
+}
+
+type conditionField struct {
+	key   string
+	value interface{}
+	path  string
+}
+
+// rawScalar is a scalar written as it was in the original document
+type rawScalar string
+
+func (converter *blueprintConverter) warn(format string, args ...interface{}) {
+	converter.warnings = append(converter.warnings, fmt.Sprintf(format, args...))
+}
+
+func (converter *blueprintConverter) addOrigin(newPath string, oldPaths ...string) {
+	converter.origins[newPath] = append(converter.origins[newPath], oldPaths...)
+}
+
+func (converter *blueprintConverter) convertDocument(doc yaml.MapSlice) yaml.MapSlice {
+	out := yaml.MapSlice{}
+	spec := yaml.MapSlice{}
+	specIndex := -1
+	docSpec, _ := getMapSliceValue(doc, "spec").(yaml.MapSlice)
+	addSpec := func() {
+		if specIndex < 0 {
+			specIndex = len(out)
+			out = append(out, yaml.MapItem{Key: "spec"})
+		}
+	}
+
+	for _, item := range doc {
+		key := fmt.Sprint(item.Key)
+		switch key {
+		case "apiVersion":
+			converter.addOrigin(key, key)
+			out = append(out, yaml.MapItem{Key: key, Value: models.BlueprintYamlFormatV2})
+		case "metadata":
+			out = append(out, yaml.MapItem{Key: key, Value: converter.convertMetadata(item.Value, key)})
+		case "parameters", "files":
+			// top level lists are supported for backward compatibility with v8.5, unless they are also set in spec
+			if getMapSliceValue(docSpec, key) != nil {
+				converter.warn("top level field [%s] is ignored by xl/v1 when spec.%s is set and was not converted", key, key)
+				continue
+			}
+			addSpec()
+			spec = append(spec, yaml.MapItem{Key: key, Value: converter.convertList(key, item.Value, key, "spec."+key)})
+		case "spec":
+			converter.addOrigin(key, key)
+			addSpec()
+			for _, specItem := range docSpec {
+				specKey := fmt.Sprint(specItem.Key)
+				spec = append(spec, yaml.MapItem{Key: specKey, Value: converter.convertList(specKey, specItem.Value, "spec."+specKey, "spec."+specKey)})
+			}
+		default:
+			out = append(out, yaml.MapItem{Key: key, Value: converter.copyValue(item.Value, key, key, key)})
+		}
+	}
+	if specIndex >= 0 {
+		out[specIndex].Value = spec
+	}
+	return out
+}
+
+func (converter *blueprintConverter) convertMetadata(value interface{}, path string) interface{} {
+	converter.addOrigin(path, path)
+	metadata, ok := value.(yaml.MapSlice)
+	if !ok {
+		return converter.copyValue(value, path, path, path)
+	}
+	out := yaml.MapSlice{}
+	for _, item := range metadata {
+		key := fmt.Sprint(item.Key)
+		oldPath := path + "." + key
+		if key == "projectName" {
+			key = "name"
+		}
+		out = append(out, yaml.MapItem{Key: key, Value: converter.copyValue(item.Value, oldPath, path+"."+key, "metadata field ["+key+"]")})
+	}
+	return out
+}
+
+func (converter *blueprintConverter) convertList(kind string, value interface{}, oldPath string, newPath string) interface{} {
+	converter.addOrigin(newPath, oldPath)
+	list, ok := value.([]interface{})
+	if !ok {
+		return converter.copyValue(value, oldPath, newPath, kind)
+	}
+	out := make([]interface{}, 0, len(list))
+	for i, element := range list {
+		elementOldPath := fmt.Sprintf("%s.%d", oldPath, i)
+		elementNewPath := fmt.Sprintf("%s.%d", newPath, i)
+		fields, ok := element.(yaml.MapSlice)
+		switch {
+		case !ok:
+			out = append(out, converter.copyValue(element, elementOldPath, elementNewPath, kind))
+		case kind == "parameters":
+			out = append(out, converter.convertParameter(fields, elementOldPath, elementNewPath))
+		case kind == "files":
+			out = append(out, converter.convertFields(fields, elementOldPath, elementNewPath, "writeIf", nil, fmt.Sprintf("file [%v]", getMapSliceValue(fields, "path"))))
+		default:
+			out = append(out, converter.copyValue(element, elementOldPath, elementNewPath, kind))
+		}
+	}
+	return out
+}
+
+// convertFields renames the fields of a parameter or file and merges its dependsOn fields into conditionKey
+func (converter *blueprintConverter) convertFields(fields yaml.MapSlice, oldPath string, newPath string, conditionKey string, renames map[string]string, context string) yaml.MapSlice {
+	converter.addOrigin(newPath, oldPath)
+	out := yaml.MapSlice{}
+	var conditions []conditionField
+	conditionIndex := -1
+	for _, item := range fields {
+		key := fmt.Sprint(item.Key)
+		fieldPath := oldPath + "." + key
+		switch key {
+		case "dependsOn", "dependsOnTrue", "dependsOnFalse":
+			conditions = append(conditions, conditionField{key: key, value: item.Value, path: fieldPath})
+			if conditionIndex < 0 {
+				conditionIndex = len(out)
+				out = append(out, yaml.MapItem{Key: conditionKey})
+			}
+			converter.addOrigin(newPath+"."+conditionKey, fieldPath)
+		default:
+			if newKey, ok := renames[key]; ok {
+				key = newKey
+			}
+			out = append(out, yaml.MapItem{Key: key, Value: converter.copyValue(item.Value, fieldPath, newPath+"."+key, context+" field ["+key+"]")})
+		}
+	}
+	if conditionIndex >= 0 {
+		out[conditionIndex].Value = converter.convertCondition(conditions, context)
+	}
+	return out
+}
+
+func (converter *blueprintConverter) convertParameter(fields yaml.MapSlice, oldPath string, newPath string) yaml.MapSlice {
+	name := fmt.Sprint(getMapSliceValue(fields, "name"))
+	context := fmt.Sprintf("parameter [%s]", name)
+	out := converter.convertFields(fields, oldPath, newPath, "promptIf", convertParameterFields, context)
+
+	// secret parameters have a secret type in v2
+	if secret := getMapSliceValue(fields, "secret"); secret != nil {
+		converter.addOrigin(newPath+".type", oldPath+".secret")
+		out = removeMapSliceKey(out, "secret")
+		if secret == true || secret == "true" {
+			if paramType, ok := getMapSliceValue(fields, "type").(string); ok {
+				out = setMapSliceValue(out, "type", getSecretType(paramType))
+			} else {
+				converter.warn("%s is secret but has no type, set a secret type by hand", context)
+			}
+		}
+	}
+
+	// pattern is replaced with a validate expression
+	if pattern := getMapSliceValue(fields, "pattern"); pattern != nil {
+		converter.addOrigin(newPath+".validate", oldPath+".pattern")
+		patternIndex := indexOfMapSliceKey(out, "pattern")
+		patternString, ok := pattern.(string)
+		switch {
+		case getMapSliceValue(out, "validate") != nil:
+			converter.warn("%s has both pattern and validate fields, the pattern was removed", context)
+			out = removeMapSliceKey(out, "pattern")
+		case !ok:
+			converter.warn("%s has a pattern that is not a string, add a validate expression by hand", context)
+			out = removeMapSliceKey(out, "pattern")
+		default:
+			out[patternIndex] = yaml.MapItem{Key: "validate", Value: yaml.CustomTag{
+				Tag:   tagExpressionV2,
+				Value: fmt.Sprintf("regex('%s', %s)", strings.Replace(patternString, "\\", "\\\\", -1), name),
+			}}
+		}
+	}
+
+	paramType, _ := getMapSliceValue(out, "type").(string)
+	if raw, ok := getMapSliceValue(out, "type").(rawScalar); ok {
+		paramType = string(raw)
+	}
+	if !IsSecretType(paramType) {
+		for _, key := range []string{"replaceAsIs", "revealOnSummary"} {
+			if getMapSliceValue(out, key) != nil {
+				converter.warn("%s field [%s] only applies to secret parameters and was removed", context, key)
+				out = removeMapSliceKey(out, key)
+			}
+		}
+	}
+	if getMapSliceValue(out, "value") != nil {
+		if getMapSliceValue(out, "promptIf") != nil {
+			converter.warn("%s has a value, its promptIf condition is not allowed in xl/v2 and was removed", context)
+			out = removeMapSliceKey(out, "promptIf")
+		}
+	} else if getMapSliceValue(out, "prompt") == nil {
+		converter.warn("%s has no description, its name is used as prompt", context)
+		out = insertMapSliceItem(out, indexOfMapSliceKey(out, "type")+1, yaml.MapItem{Key: "prompt", Value: name})
+	}
+	return out
+}
+
+// convertCondition merges dependsOn, dependsOnTrue and dependsOnFalse into a single condition
+func (converter *blueprintConverter) convertCondition(conditions []conditionField, context string) interface{} {
+	terms := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		negate := condition.key == "dependsOnFalse"
+		var expression string
+		switch val := condition.value.(type) {
+		case string:
+			if len(conditions) == 1 && !negate {
+				return converter.copyValue(val, condition.path, "", context)
+			}
+			expression = val
+		case bool:
+			if len(conditions) == 1 && !negate {
+				return val
+			}
+			expression = strconv.FormatBool(val)
+		case yaml.CustomTag:
+			converted := converter.convertTag(val, context)
+			tag, ok := converted.(yaml.CustomTag)
+			if !ok || tag.Tag != tagExpressionV2 {
+				return converted
+			}
+			expression = tag.Value
+		default:
+			converter.warn("%s has a condition [%v] that cannot be converted", context, val)
+			return condition.value
+		}
+
+		if len(conditions) > 1 || negate {
+			if !regExIdentifier.MatchString(expression) {
+				expression = "(" + expression + ")"
+			}
+		}
+		if negate {
+			expression = "!" + expression
+		}
+		terms = append(terms, expression)
+	}
+	return yaml.CustomTag{Tag: tagExpressionV2, Value: strings.Join(terms, " && ")}
+}
+
+// copyValue copies a value of the original document, converting the tags it contains
+func (converter *blueprintConverter) copyValue(value interface{}, oldPath string, newPath string, context string) interface{} {
+	if newPath != "" {
+		converter.addOrigin(newPath, oldPath)
+	}
+	switch val := value.(type) {
+	case yaml.MapSlice:
+		out := yaml.MapSlice{}
+		for _, item := range val {
+			key := fmt.Sprint(item.Key)
+			out = append(out, yaml.MapItem{Key: item.Key, Value: converter.copyValue(item.Value, oldPath+"."+key, joinPath(newPath, key), context)})
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		for i, element := range val {
+			out = append(out, converter.copyValue(element, fmt.Sprintf("%s.%d", oldPath, i), joinPath(newPath, strconv.Itoa(i)), context))
+		}
+		return out
+	case yaml.CustomTag:
+		return converter.convertTag(val, context)
+	default:
+		if source, ok := converter.sources[oldPath]; ok && source.raw != "" {
+			return rawScalar(source.raw)
+		}
+		return val
+	}
+}
+
+func (converter *blueprintConverter) convertTag(tag yaml.CustomTag, context string) interface{} {
+	switch tag.Tag {
+	case tagExpressionV1, tagExpressionV2:
+		return yaml.CustomTag{Tag: tagExpressionV2, Value: tag.Value}
+	case tagFnV1:
+		if expression, ok := convertFunction(tag.Value); ok {
+			return yaml.CustomTag{Tag: tagExpressionV2, Value: expression}
+		}
+		converter.warn("%s uses function [%s %s] which has no xl/v2 equivalent, replace it by hand", context, tag.Tag, tag.Value)
+	default:
+		converter.warn("%s uses unknown tag [%s %s], replace it by hand", context, tag.Tag, tag.Value)
+	}
+	return tag
+}
+
+// convertFunction converts a v1 function call (DOMAIN.MODULE(PARAMS...).ATTR|[INDEX]) to the equivalent expression
+func convertFunction(fnStr string) (string, bool) {
+	groups := regExFn.FindStringSubmatch(fnStr)
+	if len(groups) != 6 || groups[0] != strings.TrimSpace(fnStr) {
+		return "", false
+	}
+	domain, module, attr, index := groups[1], strings.ToLower(groups[2]), groups[4], groups[5]
+	params := []string{}
+	for _, param := range strings.Split(groups[3], ",") {
+		if param = strings.TrimSpace(param); param != "" {
+			params = append(params, param)
+		}
+	}
+
+	switch {
+	case domain == FnAWS && module == aws.Regions && len(params) == 1 && attr == "":
+		if index != "" {
+			return fmt.Sprintf("awsRegions('%s', %s)", params[0], index), true
+		}
+		return fmt.Sprintf("awsRegions('%s')", params[0]), true
+	case domain == FnAWS && module == aws.Credentials && len(params) == 0 && attr != "" && index == "":
+		return fmt.Sprintf("awsCredentials('%s')", attr), true
+	case domain == FnK8S && module == k8s.Config && len(params) <= 1 && attr != "" && index == "":
+		if len(params) == 1 {
+			return fmt.Sprintf("k8sConfig('%s', '%s')", attr, params[0]), true
+		}
+		return fmt.Sprintf("k8sConfig('%s')", attr), true
+	}
+	return "", false
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return ""
+	}
+	return path + "." + key
+}
+
+func indexOfMapSliceKey(m yaml.MapSlice, key string) int {
+	for i, item := range m {
+		if fmt.Sprint(item.Key) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func getMapSliceValue(m yaml.MapSlice, key string) interface{} {
+	if i := indexOfMapSliceKey(m, key); i >= 0 {
+		return m[i].Value
+	}
+	return nil
+}
+
+func setMapSliceValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	if i := indexOfMapSliceKey(m, key); i >= 0 {
+		m[i].Value = value
+		return m
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+func removeMapSliceKey(m yaml.MapSlice, key string) yaml.MapSlice {
+	if i := indexOfMapSliceKey(m, key); i >= 0 {
+		return append(m[:i:i], m[i+1:]...)
+	}
+	return m
+}
+
+func insertMapSliceItem(m yaml.MapSlice, index int, item yaml.MapItem) yaml.MapSlice {
+	out := append(yaml.MapSlice{}, m[:index]...)
+	out = append(out, item)
+	return append(out, m[index:]...)
+}
+
+// yamlSource holds the comments and the raw text of a key or sequence item of the original document
+type yamlSource struct {
+	comments []string
+	inline   string
+	raw      string
+}
+
+type yamlScanFrame struct {
+	col     int
+	item    bool
+	index   int
+	segment string
+}
+
+// scanYamlSources maps the comments and single line scalar values of a block style YAML document to the paths of
+// the keys they belong to, e.g. spec.parameters.0.name. Comments after the last key are returned separately.
+func scanYamlSources(content string) (map[string]*yamlSource, []string) {
+	sources := make(map[string]*yamlSource)
+	var stack []yamlScanFrame
+	var comments []string
+	blockCol := -1
+
+	currentPath := func() string {
+		segments := make([]string, 0, len(stack))
+		for _, frame := range stack {
+			if frame.item {
+				segments = append(segments, strconv.Itoa(frame.index))
+			} else {
+				segments = append(segments, frame.segment)
+			}
+		}
+		return strings.Join(segments, ".")
+	}
+	record := func(inline string, raw string) {
+		path := currentPath()
+		if _, ok := sources[path]; !ok {
+			sources[path] = &yamlSource{comments: comments, inline: inline}
+			if raw != "" && !strings.HasPrefix(raw, "!") && !strings.HasPrefix(raw, "|") && !strings.HasPrefix(raw, ">") &&
+				!strings.HasPrefix(raw, "[") && !strings.HasPrefix(raw, "{") && !strings.HasPrefix(raw, "&") && !strings.HasPrefix(raw, "*") {
+				sources[path].raw = raw
+			}
+		}
+		comments = nil
+	}
+	isBlockScalar := func(value string) bool {
+		return strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">")
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		col := len(line) - len(strings.TrimLeft(line, " "))
+		if blockCol >= 0 {
+			if trimmed == "" || col > blockCol {
+				continue
+			}
+			blockCol = -1
+		}
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			comments = append(comments, trimmed)
+			continue
+		}
+
+		rest := line[col:]
+		if rest == "-" || strings.HasPrefix(rest, "- ") {
+			index := 0
+			for len(stack) > 0 {
+				top := stack[len(stack)-1]
+				if top.col < col || (top.col == col && !top.item) {
+					break
+				}
+				if top.col == col && top.item {
+					index = top.index + 1
+					stack = stack[:len(stack)-1]
+					break
+				}
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, yamlScanFrame{col: col, item: true, index: index})
+			itemContent := strings.TrimLeft(rest[1:], " ")
+			col += len(rest) - len(itemContent)
+			rest = itemContent
+
+			value, inline := splitYamlComment(rest)
+			if !regExYamlKeyLine.MatchString(value) {
+				record(inline, value)
+				if isBlockScalar(value) {
+					blockCol = col - 1
+				}
+				continue
+			}
+		}
+
+		value, inline := splitYamlComment(rest)
+		groups := regExYamlKeyLine.FindStringSubmatch(value)
+		if groups == nil {
+			// continuation of a multi-line scalar
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].col >= col {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, yamlScanFrame{col: col, segment: groups[1]})
+		record(inline, groups[2])
+		if isBlockScalar(groups[2]) {
+			blockCol = col
+		}
+	}
+	return sources, comments
+}
+
+// splitYamlComment splits a line in its value and its inline comment, ignoring # characters in quoted strings
+func splitYamlComment(line string) (string, string) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'') && (i == 0 || line[i-1] == ' '):
+			quote = c
+		case quote == 0 && c == '#' && (i == 0 || line[i-1] == ' '):
+			return strings.TrimRight(line[:i], " "), line[i:]
+		}
+	}
+	return strings.TrimRight(line, " "), ""
+}
+
+// yamlEmitter writes a converted document in block style with the comments of the original document
+type yamlEmitter struct {
+	buf     bytes.Buffer
+	sources map[string]*yamlSource
+	origins map[string][]string
+}
+
+func (emitter *yamlEmitter) writeComments(path string, indent int) {
+	for _, origin := range emitter.origins[path] {
+		if source, ok := emitter.sources[origin]; ok {
+			for _, comment := range source.comments {
+				emitter.buf.WriteString(strings.Repeat(" ", indent) + comment + "\n")
+			}
+		}
+	}
+}
+
+func (emitter *yamlEmitter) inlineComment(path string) string {
+	for _, origin := range emitter.origins[path] {
+		if source, ok := emitter.sources[origin]; ok && source.inline != "" {
+			return " " + source.inline
+		}
+	}
+	return ""
+}
+
+// writeMap writes the keys of m at the given indent, the first key is prefixed with firstPrefix when set
+func (emitter *yamlEmitter) writeMap(m yaml.MapSlice, indent int, path string, firstPrefix string) {
+	for i, item := range m {
+		key := fmt.Sprint(item.Key)
+		itemPath := key
+		if path != "" {
+			itemPath = path + "." + key
+		}
+		prefix := strings.Repeat(" ", indent)
+		if i == 0 && firstPrefix != "" {
+			prefix = firstPrefix
+		}
+		commentIndent := indent
+		if i == 0 && firstPrefix != "" {
+			commentIndent = indent - 2
+		}
+		emitter.writeComments(itemPath, commentIndent)
+
+		switch val := item.Value.(type) {
+		case yaml.MapSlice:
+			emitter.buf.WriteString(prefix + key + ":" + emitter.inlineComment(itemPath) + "\n")
+			emitter.writeMap(val, indent+2, itemPath, "")
+		case []interface{}:
+			if len(val) == 0 {
+				emitter.buf.WriteString(prefix + key + ": []" + emitter.inlineComment(itemPath) + "\n")
+				continue
+			}
+			emitter.buf.WriteString(prefix + key + ":" + emitter.inlineComment(itemPath) + "\n")
+			emitter.writeList(val, indent, itemPath)
+		default:
+			emitter.writeScalar(prefix+key+": ", val, indent, emitter.inlineComment(itemPath))
+		}
+	}
+}
+
+func (emitter *yamlEmitter) writeList(list []interface{}, indent int, path string) {
+	prefix := strings.Repeat(" ", indent) + "- "
+	for i, element := range list {
+		elementPath := fmt.Sprintf("%s.%d", path, i)
+		switch val := element.(type) {
+		case yaml.MapSlice:
+			emitter.writeMap(val, indent+2, elementPath, prefix)
+		case []interface{}:
+			emitter.writeComments(elementPath, indent)
+			emitter.buf.WriteString(prefix + "\n")
+			emitter.writeList(val, indent+2, elementPath)
+		default:
+			emitter.writeComments(elementPath, indent)
+			emitter.writeScalar(prefix, val, indent, emitter.inlineComment(elementPath))
+		}
+	}
+}
+
+func (emitter *yamlEmitter) writeScalar(prefix string, value interface{}, indent int, inline string) {
+	var text string
+	switch val := value.(type) {
+	case rawScalar:
+		text = string(val)
+	case yaml.CustomTag:
+		text = val.Tag + " " + strconv.Quote(val.Value)
+	default:
+		out, err := yaml.Marshal(yaml.MapSlice{{Key: "k", Value: value}})
+		if err != nil {
+			text = fmt.Sprint(value)
+			break
+		}
+		lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(string(out), "k: "), "\n"), "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = strings.Repeat(" ", indent) + lines[i]
+			}
+		}
+		lines[0] += inline
+		emitter.buf.WriteString(prefix + strings.Join(lines, "\n") + "\n")
+		return
+	}
+	emitter.buf.WriteString(prefix + text + inline + "\n")
+}
@@ -0,0 +1,143 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertBlueprintV1(t *testing.T) {
+	t.Run("should convert a v1 blueprint to a valid v2 blueprint", func(t *testing.T) {
+		content, err := ioutil.ReadFile(filepath.Join("..", "..", "templates", "test", "valid-no-prompt-v1", "blueprint.yaml"))
+		require.Nil(t, err)
+
+		conversion, err := ConvertBlueprintV1(content)
+		require.Nil(t, err)
+		assert.Empty(t, conversion.Warnings)
+
+		converted, err := parseTemplateMetadataV2(&conversion.Content, "", nil)
+		require.Nil(t, err)
+		original, err := parseTemplateMetadataV1(&content, "", nil)
+		require.Nil(t, err)
+		require.Len(t, converted.Variables, len(original.Variables))
+		for i, variable := range original.Variables {
+			assert.Equal(t, variable.Name, converted.Variables[i].Name)
+			assert.Equal(t, variable.Type, converted.Variables[i].Type)
+			assert.Equal(t, variable.Prompt, converted.Variables[i].Prompt)
+			assert.Equal(t, variable.Value.Value, converted.Variables[i].Value.Value)
+			assert.Equal(t, variable.Validate.Value, converted.Variables[i].Validate.Value)
+		}
+		assert.Equal(t, "Test Project", converted.Metadata.Name)
+		assert.Equal(t, "!TestDepends", converted.TemplateConfigs[3].DependsOn.Value)
+	})
+
+	t.Run("should keep comments and unchanged values", func(t *testing.T) {
+		conversion, err := ConvertBlueprintV1([]byte(`# Test blueprint
+apiVersion: xl/v1
+kind: Blueprint
+metadata:
+  projectName: Test # project name
+  version: 1.0
+spec:
+  parameters:
+    # the application name
+    - name: AppName
+      type: Input
+      description: "Application #name"
+      dependsOnTrue: UseApp
+      dependsOnFalse: !expression "Region == 'us-west'"
+    - name: Password
+      type: Input
+      secret: true # never shown
+      useRawValue: true
+      value: secret
+  files:
+  - path: app.yaml
+    dependsOnFalse: UseApp
+# end of blueprint
+`))
+		require.Nil(t, err)
+		assert.Empty(t, conversion.Warnings)
+		assert.Equal(t, `# Test blueprint
+apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  name: Test # project name
+  version: 1.0
+spec:
+  parameters:
+  # the application name
+  - name: AppName
+    type: Input
+    prompt: "Application #name"
+    promptIf: !expr "UseApp && !(Region == 'us-west')"
+  - name: Password
+    type: SecretInput # never shown
+    replaceAsIs: true
+    value: secret
+  files:
+  - path: app.yaml
+    writeIf: !expr "!UseApp"
+# end of blueprint
+`, string(conversion.Content))
+	})
+
+	t.Run("should report what cannot be converted", func(t *testing.T) {
+		conversion, err := ConvertBlueprintV1([]byte(`
+apiVersion: xl/v1
+kind: Blueprint
+parameters:
+- name: ApiServer
+  type: Input
+  value: !fn os._defaultapiserverurl()
+- name: Region
+  type: Select
+  options:
+  - !fn aws.regions(ecs)
+  showValueOnSummary: true
+  dependsOnTrue: UseAWS
+`))
+		require.Nil(t, err)
+		assert.Equal(t, []string{
+			"parameter [ApiServer] field [value] uses function [!fn os._defaultapiserverurl()] which has no xl/v2 equivalent, replace it by hand",
+			"parameter [Region] field [revealOnSummary] only applies to secret parameters and was removed",
+			"parameter [Region] has no description, its name is used as prompt",
+			"the converted blueprint is not valid, please fix it by hand: unknown tag !fn os._defaultapiserverurl(), supported tags are [!expr]",
+		}, conversion.Warnings)
+		assert.Contains(t, string(conversion.Content), "  - !expr \"awsRegions('ecs')\"\n")
+		assert.Contains(t, string(conversion.Content), "    prompt: Region\n")
+		assert.Contains(t, string(conversion.Content), "    promptIf: UseAWS\n")
+	})
+
+	t.Run("should only convert v1 blueprints", func(t *testing.T) {
+		_, err := ConvertBlueprintV1([]byte("apiVersion: xl/v2\nkind: Blueprint\n"))
+		require.NotNil(t, err)
+		assert.Equal(t, "apiVersion [xl/v2] is not supported, only xl/v1 blueprints can be converted", err.Error())
+	})
+}
+
+func TestConvertFunction(t *testing.T) {
+	tests := []struct {
+		fn   string
+		want string
+		ok   bool
+	}{
+		{"aws.regions(ecs)", "awsRegions('ecs')", true},
+		{"aws.regions(ecs)[0]", "awsRegions('ecs', 0)", true},
+		{"aws.credentials().AccessKeyID", "awsCredentials('AccessKeyID')", true},
+		{"aws.credentials(default).AccessKeyID", "", false},
+		{"k8s.config().ClusterServer", "k8sConfig('ClusterServer')", true},
+		{"k8s.config(minikube).IsAvailable", "k8sConfig('IsAvailable', 'minikube')", true},
+		{"os._defaultapiserverurl()", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fn, func(t *testing.T) {
+			got, ok := convertFunction(tt.fn)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package blueprint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+const (
+	// MarkdownIndexFileName is the name of the index page written for the Markdown documents of a repository
+	MarkdownIndexFileName = "index.md"
+	markdownDocExtension  = ".md"
+)
+
+// BlueprintDescription is the fully composed question set and file list of a blueprint
+type BlueprintDescription struct {
+	Path         string                 `json:"path"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Author       string                 `json:"author"`
+	Version      string                 `json:"version"`
+	Tags         []string               `json:"tags,omitempty"`
+	Instructions string                 `json:"instructions,omitempty"`
+	Parameters   []ParameterDescription `json:"parameters"`
+	Files        []FileDescription      `json:"files"`
+	Includes     []IncludeDescription   `json:"includes"`
+}
+
+// ParameterDescription describes a single parameter of a composed blueprint
+type ParameterDescription struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type,omitempty"`
+	Prompt       string   `json:"prompt,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Value        string   `json:"value,omitempty"`
+	Default      string   `json:"default,omitempty"`
+	Options      []string `json:"options,omitempty"`
+	Validate     string   `json:"validate,omitempty"`
+	PromptIf     string   `json:"promptIf,omitempty"`
+	Secret       bool     `json:"secret"`
+	SaveInXlvals bool     `json:"saveInXlvals"`
+	Blueprint    string   `json:"blueprint"`
+	IncludeIf    []string `json:"includeIf,omitempty"`
+}
+
+// FileDescription describes a single file of a composed blueprint
+type FileDescription struct {
+	Path      string   `json:"path"`
+	RenameTo  string   `json:"renameTo,omitempty"`
+	WriteIf   string   `json:"writeIf,omitempty"`
+	Blueprint string   `json:"blueprint"`
+	IncludeIf []string `json:"includeIf,omitempty"`
+}
+
+// IncludeDescription describes a blueprint included by another one, directly or through a nested include
+type IncludeDescription struct {
+	Blueprint  string   `json:"blueprint"`
+	IncludedBy string   `json:"includedBy"`
+	IncludeIf  []string `json:"includeIf,omitempty"`
+}
+
+// DescribeBlueprint resolves the full composition of a blueprint in the active repository without asking any question
+func (blueprintContext *BlueprintContext) DescribeBlueprint(templatePath string) (*BlueprintDescription, error) {
+	blueprints, err := blueprintContext.initCurrentRepoClient()
+	if err != nil {
+		return nil, err
+	}
+	blueprintDocs, masterBlueprintDoc, err := getBlueprintConfig(blueprintContext, blueprints, templatePath, []VarField{{}}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	description := &BlueprintDescription{
+		Path:         templatePath,
+		Name:         masterBlueprintDoc.Metadata.Name,
+		Description:  strings.TrimSpace(masterBlueprintDoc.Metadata.Description),
+		Author:       masterBlueprintDoc.Metadata.Author,
+		Version:      masterBlueprintDoc.Metadata.Version,
+		Tags:         masterBlueprintDoc.Metadata.Tags,
+		Instructions: strings.TrimSpace(masterBlueprintDoc.Metadata.Instructions),
+		Parameters:   make([]ParameterDescription, 0),
+		Files:        make([]FileDescription, 0),
+		Includes:     make([]IncludeDescription, 0),
+	}
+	// blueprints are listed in the order they are processed, so that parameters are in question order
+	for _, blueprintDoc := range blueprintDocs {
+		includeIf := getIncludeConditions(blueprintDoc)
+		if blueprintDoc.Parent != "" {
+			description.Includes = append(description.Includes, IncludeDescription{
+				Blueprint:  blueprintDoc.Name,
+				IncludedBy: blueprintDoc.Parent,
+				IncludeIf:  includeIf,
+			})
+		}
+		for _, variable := range blueprintDoc.BlueprintConfig.Variables {
+			description.Parameters = append(description.Parameters, describeVariable(variable, blueprintDoc.Name, includeIf))
+		}
+		for _, config := range blueprintDoc.BlueprintConfig.TemplateConfigs {
+			description.Files = append(description.Files, FileDescription{
+				Path:      config.Path,
+				RenameTo:  formatVarField(config.RenameTo),
+				WriteIf:   formatVarField(config.DependsOn),
+				Blueprint: blueprintDoc.Name,
+				IncludeIf: includeIf,
+			})
+		}
+	}
+	return description, nil
+}
+
+func describeVariable(variable Variable, blueprintName string, includeIf []string) ParameterDescription {
+	parameter := ParameterDescription{
+		Name:         variable.Name.Value,
+		Type:         variable.Type.Value,
+		Prompt:       formatVarField(variable.Prompt),
+		Description:  formatVarField(variable.Description),
+		Value:        formatVarField(variable.Value),
+		Default:      formatVarField(variable.Default),
+		Validate:     formatVarField(variable.Validate),
+		PromptIf:     formatVarField(variable.DependsOn),
+		Secret:       IsSecretType(variable.Type.Value),
+		SaveInXlvals: variable.SaveInXlvals.Bool,
+		Blueprint:    blueprintName,
+		IncludeIf:    includeIf,
+	}
+	for _, option := range variable.Options {
+		if option.Tag != "" {
+			parameter.Options = append(parameter.Options, formatVarField(option))
+		} else {
+			parameter.Options = append(parameter.Options, getOptionTextWithLabel(option))
+		}
+	}
+	return parameter
+}
+
+// getIncludeConditions returns the includeIf conditions that apply to a composed blueprint and its parents
+func getIncludeConditions(blueprintDoc *ComposedBlueprint) []string {
+	var includeIf []string
+	for _, dependsOn := range blueprintDoc.DependsOn {
+		if condition := formatVarField(dependsOn); condition != "" {
+			includeIf = append(includeIf, condition)
+		}
+	}
+	return includeIf
+}
+
+// formatVarField returns the field as written in blueprint.yaml, including its tag
+func formatVarField(field VarField) string {
+	if field.Value == "" {
+		return ""
+	}
+	text := field.Value
+	if field.Tag != "" {
+		text = fmt.Sprintf("%s %s", field.Tag, field.Value)
+	}
+	if field.InvertBool {
+		text = "not " + text
+	}
+	return text
+}
+
+// WriteMarkdown writes the blueprint description as a Markdown document
+func (description *BlueprintDescription) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	title := description.Name
+	if title == "" {
+		title = description.Path
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	if description.Description != "" {
+		fmt.Fprintf(&sb, "%s\n\n", description.Description)
+	}
+	sb.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&sb, "| Path | `%s` |\n", description.Path)
+	fmt.Fprintf(&sb, "| Version | %s |\n", markdownCell(description.Version))
+	fmt.Fprintf(&sb, "| Author | %s |\n", markdownCell(description.Author))
+	if len(description.Tags) > 0 {
+		fmt.Fprintf(&sb, "| Tags | %s |\n", markdownCell(strings.Join(description.Tags, ", ")))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Parameters\n\n")
+	if len(description.Parameters) == 0 {
+		sb.WriteString("This blueprint has no parameters.\n\n")
+	} else {
+		sb.WriteString("| Name | Type | Prompt | Description | Value | Default | Options | Validate | Prompt if | Blueprint |\n")
+		sb.WriteString("|---|---|---|---|---|---|---|---|---|---|\n")
+		for _, parameter := range description.Parameters {
+			parameterType := parameter.Type
+			if parameter.SaveInXlvals {
+				parameterType = strings.TrimSpace(parameterType + " (saved in xlvals)")
+			}
+			fmt.Fprintf(
+				&sb, "| `%s` | %s | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+				parameter.Name,
+				markdownCell(parameterType),
+				markdownCell(parameter.Prompt),
+				markdownCell(parameter.Description),
+				markdownCode(parameter.Value),
+				markdownCode(parameter.Default),
+				markdownCell(strings.Join(parameter.Options, ", ")),
+				markdownCode(parameter.Validate),
+				markdownCode(joinConditions(parameter.IncludeIf, parameter.PromptIf)),
+				markdownCell(parameter.Blueprint),
+			)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Files\n\n")
+	if len(description.Files) == 0 {
+		sb.WriteString("This blueprint has no files.\n\n")
+	} else {
+		sb.WriteString("| Path | Rename to | Write if | Blueprint |\n")
+		sb.WriteString("|---|---|---|---|\n")
+		for _, file := range description.Files {
+			fmt.Fprintf(
+				&sb, "| `%s` | %s | %s | %s |\n",
+				file.Path,
+				markdownCode(file.RenameTo),
+				markdownCode(joinConditions(file.IncludeIf, file.WriteIf)),
+				markdownCell(file.Blueprint),
+			)
+		}
+		sb.WriteString("\n")
+		writeMarkdownFilesByCondition(&sb, description.Files)
+	}
+
+	if len(description.Includes) > 0 {
+		sb.WriteString("## Included Blueprints\n\n")
+		sb.WriteString("| Blueprint | Included by | Include if |\n")
+		sb.WriteString("|---|---|---|\n")
+		for _, include := range description.Includes {
+			fmt.Fprintf(
+				&sb, "| %s | %s | %s |\n",
+				markdownCell(include.Blueprint),
+				markdownCell(include.IncludedBy),
+				markdownCode(joinConditions(include.IncludeIf, "")),
+			)
+		}
+		sb.WriteString("\n")
+	}
+
+	if description.Instructions != "" {
+		fmt.Fprintf(&sb, "## Instructions\n\n%s\n", description.Instructions)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMarkdownFilesByCondition groups the files by the condition they are written on, in the order the conditions first
+// appear, nothing is written when all files are always written
+func writeMarkdownFilesByCondition(sb *strings.Builder, files []FileDescription) {
+	var conditions []string
+	filesByCondition := make(map[string][]string)
+	for _, file := range files {
+		condition := joinConditions(file.IncludeIf, file.WriteIf)
+		if _, ok := filesByCondition[condition]; !ok {
+			conditions = append(conditions, condition)
+		}
+		// the same file can be written by several included blueprints on the same condition
+		fileCell := fmt.Sprintf("`%s`", markdownCell(file.Path))
+		if !util.IsStringInSlice(fileCell, filesByCondition[condition]) {
+			filesByCondition[condition] = append(filesByCondition[condition], fileCell)
+		}
+	}
+	if len(conditions) == 1 && conditions[0] == "" {
+		return
+	}
+
+	sb.WriteString("### Files by Condition\n\n")
+	sb.WriteString("| Condition | Files |\n")
+	sb.WriteString("|---|---|\n")
+	for _, condition := range conditions {
+		conditionCell := "always"
+		if condition != "" {
+			conditionCell = markdownCode(condition)
+		}
+		fmt.Fprintf(sb, "| %s | %s |\n", conditionCell, strings.Join(filesByCondition[condition], ", "))
+	}
+	sb.WriteString("\n")
+}
+
+// MarkdownDocPath returns the path of the Markdown document of a blueprint, relative to the index page of its repository
+func MarkdownDocPath(blueprintPath string) string {
+	return blueprintPath + markdownDocExtension
+}
+
+// WriteMarkdownIndex writes an index page of a repository linking to the Markdown documents of its blueprints,
+// fragments are left out since they are only used through includes
+func WriteMarkdownIndex(w io.Writer, repositoryName string, summaries []BlueprintSummary) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Blueprints of %s\n\n", repositoryName)
+	sb.WriteString("| Blueprint | Name | Version | Description |\n")
+	sb.WriteString("|---|---|---|---|\n")
+	for _, summary := range summaries {
+		if summary.Fragment {
+			continue
+		}
+		if summary.Error != "" {
+			fmt.Fprintf(&sb, "| %s |  |  | (invalid: %s) |\n", markdownCell(summary.Path), markdownCell(summary.Error))
+			continue
+		}
+		fmt.Fprintf(
+			&sb, "| [%s](%s) | %s | %s | %s |\n",
+			markdownCell(summary.Path),
+			MarkdownDocPath(summary.Path),
+			markdownCell(summary.Name),
+			markdownCell(summary.Version),
+			markdownCell(summary.Description),
+		)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteRepositoryMarkdown writes the Markdown document of every blueprint of the active repository to outputDir,
+// together with an index page, and returns the written files relative to outputDir.
+// A blueprint that cannot be described does not stop the others, it is listed as invalid in the index.
+func (blueprintContext *BlueprintContext) WriteRepositoryMarkdown(outputDir string) ([]string, error) {
+	summaries, err := blueprintContext.ListBlueprints("")
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for i, summary := range summaries {
+		if summary.Fragment || summary.Error != "" {
+			continue
+		}
+		description, err := blueprintContext.DescribeBlueprint(summary.Path)
+		if err != nil {
+			summaries[i].Error = err.Error()
+			continue
+		}
+		docPath := MarkdownDocPath(summary.Path)
+		if err := writeMarkdownFile(filepath.Join(outputDir, filepath.FromSlash(docPath)), description.WriteMarkdown); err != nil {
+			return written, err
+		}
+		written = append(written, docPath)
+	}
+
+	err = writeMarkdownFile(filepath.Join(outputDir, MarkdownIndexFileName), func(w io.Writer) error {
+		return WriteMarkdownIndex(w, (*blueprintContext.ActiveRepo).GetName(), summaries)
+	})
+	if err != nil {
+		return written, err
+	}
+	return append(written, MarkdownIndexFileName), nil
+}
+
+func writeMarkdownFile(filePath string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := write(&out); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, out.Bytes(), 0644)
+}
+
+// joinConditions combines the includeIf conditions of the parent blueprints with the condition of the item itself
+func joinConditions(includeIf []string, condition string) string {
+	conditions := append([]string{}, includeIf...)
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+	return strings.Join(conditions, " and ")
+}
+
+// markdownCell escapes a value so that it fits in a single Markdown table cell
+func markdownCell(value string) string {
+	value = strings.Replace(strings.TrimSpace(value), "|", "\\|", -1)
+	return strings.Replace(value, "\n", "<br>", -1)
+}
+
+func markdownCode(value string) string {
+	value = strings.Trim(value, " ")
+	if value == "" || strings.Contains(value, "\n") {
+		return markdownCell(value)
+	}
+	return fmt.Sprintf("`%s`", markdownCell(value))
+}
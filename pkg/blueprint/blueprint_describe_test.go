@@ -0,0 +1,191 @@
+package blueprint
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprintContext_DescribeBlueprint(t *testing.T) {
+	t.Run("should describe the composed parameters and files in question order", func(t *testing.T) {
+		description, err := getLocalTestBlueprintContext(t).DescribeBlueprint("composed")
+		require.Nil(t, err)
+
+		assert.Equal(t, "composed", description.Path)
+		assert.Equal(t, "Test Project", description.Name)
+		assert.Equal(t, "1.0", description.Version)
+
+		// included before blueprint comes first, then the blueprint itself, then the included after blueprint
+		assert.Equal(t, ParameterDescription{
+			Name:         "TestFoo",
+			Value:        "hello",
+			PromptIf:     "!expr 3 > 2",
+			SaveInXlvals: true,
+			Blueprint:    "valid-no-prompt",
+		}, description.Parameters[0])
+
+		var blueprintNames []string
+		for _, parameter := range description.Parameters {
+			if len(blueprintNames) == 0 || blueprintNames[len(blueprintNames)-1] != parameter.Blueprint {
+				blueprintNames = append(blueprintNames, parameter.Blueprint)
+			}
+		}
+		assert.Equal(t, []string{"valid-no-prompt", "composed", "defaults-as-values"}, blueprintNames)
+
+		var testParameter ParameterDescription
+		for _, parameter := range description.Parameters {
+			if parameter.Name == "Test" {
+				testParameter = parameter
+			}
+		}
+		assert.Equal(t, ParameterDescription{
+			Name:         "Test",
+			Type:         TypeInput,
+			Prompt:       "Test Prompt",
+			Value:        "!expr TestCompose",
+			Default:      "testing",
+			SaveInXlvals: true,
+			Blueprint:    "defaults-as-values",
+		}, testParameter)
+
+		assert.Contains(t, description.Files, FileDescription{
+			Path:      "xlr-pipeline.yml",
+			RenameTo:  "xlr-pipeline-new.yml",
+			WriteIf:   "!expr TestDepends",
+			Blueprint: "valid-no-prompt",
+		})
+		assert.Contains(t, description.Files, FileDescription{
+			Path:      "xlr-pipeline-4.yml",
+			WriteIf:   "!expr TestDepends3",
+			Blueprint: "composed",
+		})
+	})
+
+	t.Run("should include conditions of nested included blueprints", func(t *testing.T) {
+		description, err := getLocalTestBlueprintContext(t).DescribeBlueprint("compose-nested")
+		require.Nil(t, err)
+
+		for _, parameter := range description.Parameters {
+			if parameter.Name == "TestCompose" {
+				assert.Equal(t, []string{"!expr true"}, parameter.IncludeIf)
+				assert.Equal(t, "composed", parameter.Blueprint)
+			}
+		}
+		assert.Contains(t, description.Includes, IncludeDescription{Blueprint: "composed", IncludedBy: "compose-nested", IncludeIf: []string{"!expr true"}})
+	})
+
+	t.Run("should flag secrets and list options with labels", func(t *testing.T) {
+		description, err := getLocalTestBlueprintContext(t).DescribeBlueprint("answer-input")
+		require.Nil(t, err)
+
+		parameters := make(map[string]ParameterDescription)
+		for _, parameter := range description.Parameters {
+			parameters[parameter.Name] = parameter
+		}
+		assert.True(t, parameters["AWSAccessKey"].Secret)
+		assert.False(t, parameters["AppName"].Secret)
+	})
+
+	t.Run("should fail for unknown blueprint", func(t *testing.T) {
+		_, err := getLocalTestBlueprintContext(t).DescribeBlueprint("unknown")
+		require.NotNil(t, err)
+	})
+}
+
+func TestBlueprintDescription_WriteMarkdown(t *testing.T) {
+	t.Run("should write the metadata, parameters and files", func(t *testing.T) {
+		description := &BlueprintDescription{
+			Path:    "aws/app",
+			Name:    "App",
+			Version: "1.0",
+			Parameters: []ParameterDescription{
+				{Name: "Region", Type: TypeSelect, Prompt: "Which region?", Description: "AWS region", Options: []string{"eu", "us"}, Default: "eu", SaveInXlvals: true, Blueprint: "aws/app"},
+				{Name: "Size", Type: TypeInput, Prompt: "Size | in GB", PromptIf: "Custom", IncludeIf: []string{"!expr UseDisk"}, Blueprint: "aws/disk"},
+			},
+			Files: []FileDescription{
+				{Path: "app.yaml.tmpl", Blueprint: "aws/app"},
+			},
+		}
+		var out bytes.Buffer
+		require.Nil(t, description.WriteMarkdown(&out))
+
+		assert.Equal(t, "# App\n\n"+
+			"| | |\n|---|---|\n| Path | `aws/app` |\n| Version | 1.0 |\n| Author |  |\n\n"+
+			"## Parameters\n\n"+
+			"| Name | Type | Prompt | Description | Value | Default | Options | Validate | Prompt if | Blueprint |\n"+
+			"|---|---|---|---|---|---|---|---|---|---|\n"+
+			"| `Region` | Select (saved in xlvals) | Which region? | AWS region |  | `eu` | eu, us |  |  | aws/app |\n"+
+			"| `Size` | Input | Size \\| in GB |  |  |  |  |  | `!expr UseDisk and Custom` | aws/disk |\n\n"+
+			"## Files\n\n"+
+			"| Path | Rename to | Write if | Blueprint |\n"+
+			"|---|---|---|---|\n"+
+			"| `app.yaml.tmpl` |  |  | aws/app |\n\n", out.String())
+	})
+
+	t.Run("should write the files of each condition and the included blueprints", func(t *testing.T) {
+		description := &BlueprintDescription{
+			Path:         "aws/app",
+			Tags:         []string{"aws", "eks"},
+			Instructions: "Run xl apply",
+			Files: []FileDescription{
+				{Path: "app.yaml.tmpl", WriteIf: "!expr Region == 'eu'", Blueprint: "aws/app"},
+				{Path: "README.md", Blueprint: "aws/app"},
+				{Path: "disk.yaml", IncludeIf: []string{"!expr UseDisk"}, Blueprint: "aws/disk"},
+				{Path: "eu.yaml", WriteIf: "!expr Region == 'eu'", Blueprint: "aws/app"},
+			},
+			Includes: []IncludeDescription{
+				{Blueprint: "aws/disk", IncludedBy: "aws/app", IncludeIf: []string{"!expr UseDisk"}},
+			},
+		}
+		var out bytes.Buffer
+		require.Nil(t, description.WriteMarkdown(&out))
+
+		assert.Contains(t, out.String(), "| Tags | aws, eks |\n")
+		assert.Contains(t, out.String(), "### Files by Condition\n\n"+
+			"| Condition | Files |\n"+
+			"|---|---|\n"+
+			"| `!expr Region == 'eu'` | `app.yaml.tmpl`, `eu.yaml` |\n"+
+			"| always | `README.md` |\n"+
+			"| `!expr UseDisk` | `disk.yaml` |\n\n"+
+			"## Included Blueprints\n\n"+
+			"| Blueprint | Included by | Include if |\n"+
+			"|---|---|---|\n"+
+			"| aws/disk | aws/app | `!expr UseDisk` |\n\n"+
+			"## Instructions\n\nRun xl apply\n")
+	})
+}
+
+func TestWriteMarkdownIndex(t *testing.T) {
+	var out bytes.Buffer
+	require.Nil(t, WriteMarkdownIndex(&out, "Test", []BlueprintSummary{
+		{Path: "aws/app", Name: "App", Version: "1.0", Description: "An app"},
+		{Path: "broken", Error: "parameter AppName must have a 'prompt' field"},
+		{Path: "fragments/disk", Name: "Disk", Fragment: true},
+	}))
+	assert.Equal(t, "# Blueprints of Test\n\n"+
+		"| Blueprint | Name | Version | Description |\n"+
+		"|---|---|---|---|\n"+
+		"| [aws/app](aws/app.md) | App | 1.0 | An app |\n"+
+		"| broken |  |  | (invalid: parameter AppName must have a 'prompt' field) |\n", out.String())
+}
+
+func TestBlueprintContext_WriteRepositoryMarkdown(t *testing.T) {
+	outputDir, err := ioutil.TempDir("", "blueprintmarkdown")
+	require.Nil(t, err)
+	defer os.RemoveAll(outputDir)
+
+	written, err := getLocalTestBlueprintContext(t).WriteRepositoryMarkdown(outputDir)
+	require.Nil(t, err)
+	assert.Contains(t, written, "composed.md")
+	assert.Equal(t, MarkdownIndexFileName, written[len(written)-1])
+
+	index := GetFileContent(filepath.Join(outputDir, MarkdownIndexFileName))
+	assert.Contains(t, index, "| [composed](composed.md) | Test Project | 1.0 |")
+	assert.Contains(t, index, "| invalid |  |  | (invalid: parameter AppName must have a 'prompt' field) |")
+	assert.Contains(t, GetFileContent(filepath.Join(outputDir, "composed.md")), "## Included Blueprints")
+}
@@ -467,6 +467,10 @@ func (blueprintDoc *BlueprintConfig) validate() error {
 	if err != nil {
 		return err
 	}
+	err = validateXebiaLabsFolder(blueprintDoc.Metadata.XebiaLabsFolder)
+	if err != nil {
+		return err
+	}
 	return validateFiles(&blueprintDoc.TemplateConfigs)
 }
 
@@ -476,22 +480,33 @@ func (blueprintDoc *BlueprintConfig) prepareTemplateData(params BlueprintParams,
 	var answerMap map[string]string
 	var err error
 	usingAnswersFile := false
-	if params.AnswersFile != "" || params.AnswersMap != nil {
-		if params.AnswersMap != nil {
-			util.Verbose("[dataPrep] Using answers map (strict: %t) instead of asking questions from console\n", params.StrictAnswers)
-			answerMap = params.AnswersMap
-		} else {
+	if params.hasAnswers() {
+		answerMap = make(map[string]string)
+		if params.AnswersFile != "" {
 			// parse answers file
 			util.Verbose("[dataPrep] Using answers file [%s] (strict: %t) instead of asking questions from console\n", params.AnswersFile, params.StrictAnswers)
-			answerMap, err = GetValuesFromAnswersFile(params.AnswersFile)
+			fileAnswers, err := GetValuesFromAnswersFile(params.AnswersFile)
 			if err != nil {
 				return nil, err
 			}
+			util.CopyIntoStringStringMap(fileAnswers, answerMap)
 		}
-
-		// skip final prompt if in strict answers mode
-		if params.StrictAnswers {
-			SkipFinalPrompt = true
+		if params.AnswersEnvPrefix != "" {
+			// environment variables take precedence over the answers file, so that secrets do not have to be written in it
+			util.Verbose("[dataPrep] Using answers from environment variables with prefix [%s] (strict: %t)\n", params.AnswersEnvPrefix, params.StrictAnswers)
+			util.CopyIntoStringStringMap(GetValuesFromEnvironment(params.AnswersEnvPrefix, os.Environ()), answerMap)
+		}
+		if params.AnswersMap != nil {
+			// answers given directly, for instance with --set, take precedence over the answers file
+			util.Verbose("[dataPrep] Using answers map (strict: %t) instead of asking questions from console\n", params.StrictAnswers)
+			util.CopyIntoStringStringMap(params.AnswersMap, answerMap)
+		}
+		if params.AnswerFiles != nil {
+			fileAnswers, err := getAnswersFromFiles(blueprintDoc.Variables, params.AnswerFiles)
+			if err != nil {
+				return nil, err
+			}
+			util.CopyIntoStringStringMap(fileAnswers, answerMap)
 		}
 		usingAnswersFile = true
 	}
@@ -516,6 +531,9 @@ func (blueprintDoc *BlueprintConfig) prepareTemplateData(params BlueprintParams,
 				delete(answerMap, variable.Name.Value)
 			}
 		} else {
+			if util.MapContainsKeyWithVal(params.OverrideDefaults, variable.Name.Value) {
+				util.Verbose("[dataPrep] Ignoring default override for parameter [%s] because it does not have overrideDefault set\n", variable.Name.Value)
+			}
 			// process default field value
 			defaultVal = variable.GetDefaultVal()
 		}
@@ -561,7 +579,11 @@ func (blueprintDoc *BlueprintConfig) prepareTemplateData(params BlueprintParams,
 				}
 				// if we have a valid answer, save it and skip user input
 				saveItemToTemplateDataMap(&variable, data, answer)
-				util.Info("[dataPrep] Using answer file value [%v] for variable [%s]\n", answer, variable.Name.Value)
+				loggedAnswer := answer
+				if IsSecretType(variable.Type.Value) && !variable.RevealOnSummary.Bool {
+					loggedAnswer = "*****"
+				}
+				util.Info("[dataPrep] Using answer value [%v] for variable [%s]\n", loggedAnswer, variable.Name.Value)
 				continue
 			}
 		}
@@ -596,7 +618,7 @@ func (blueprintDoc *BlueprintConfig) prepareTemplateData(params BlueprintParams,
 		// * if answers file is not present or isPartial is set to TRUE and answer not found on file for the variable
 		util.Verbose("[dataPrep] Processing template variable [Name: %s, Type: %s]\n", variable.Name.Value, variable.Type.Value)
 		var answer interface{}
-		if shouldAskForInput(variable) {
+		if !params.SkipUserInput && shouldAskForInput(variable) {
 			answer, err = variable.GetUserInput(defaultVal, data.TemplateData, overrideFns, surveyOpts...)
 		}
 		if err != nil {
@@ -641,6 +663,41 @@ func GetValuesFromAnswersFile(answersFilePath string) (map[string]string, error)
 	return nil, fmt.Errorf("blueprint answers file not found in path %s", answersFilePath)
 }
 
+// GetValuesFromEnvironment returns the answers found in the environment, the name of the variables is the prefix
+// followed by the parameter name, for instance BLUEPRINT_ANSWER_AppName
+func GetValuesFromEnvironment(prefix string, environ []string) map[string]string {
+	answers := make(map[string]string)
+	for _, entry := range environ {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) == 2 && strings.HasPrefix(kv[0], prefix) && len(kv[0]) > len(prefix) {
+			answers[strings.TrimPrefix(kv[0], prefix)] = kv[1]
+		}
+	}
+	return answers
+}
+
+// getAnswersFromFiles returns the answers given as files for the variables of a blueprint. File variables read the file
+// themselves and get its path, the other variables get its content.
+func getAnswersFromFiles(variables []Variable, answerFiles map[string]string) (map[string]string, error) {
+	answers := make(map[string]string)
+	for _, variable := range variables {
+		filePath, ok := answerFiles[variable.Name.Value]
+		if !ok {
+			continue
+		}
+		if variable.Type.Value == TypeFile || variable.Type.Value == TypeSecretFile {
+			answers[variable.Name.Value] = filePath
+			continue
+		}
+		content, err := getFileContents(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the answer of variable [%s]: %s", variable.Name.Value, err)
+		}
+		answers[variable.Name.Value] = content
+	}
+	return answers, nil
+}
+
 // utility functions
 func getFileContents(filepath string) (string, error) {
 	data, err := ioutil.ReadFile(filepath)
@@ -686,6 +743,16 @@ func validateFiles(configs *[]TemplateConfig) error {
 	return nil
 }
 
+func validateXebiaLabsFolder(folder string) error {
+	if folder == "" {
+		return nil
+	}
+	if filepath.IsAbs(folder) || strings.HasPrefix(filepath.Clean(folder), "..") {
+		return fmt.Errorf("xebialabsFolder must be a path inside the output directory")
+	}
+	return nil
+}
+
 func validatePrompt(varName string, validateExpr string, allowEmpty bool, parameters map[string]interface{}, overrideFns ExpressionOverrideFn) func(val interface{}) error {
 	return func(val interface{}) error {
 		var value interface{}
@@ -1379,6 +1379,160 @@ func TestBlueprintYaml_prepareTemplateData(t *testing.T) {
 	}
 }
 
+func TestBlueprintYaml_prepareTemplateData_answersPrecedence(t *testing.T) {
+	SkipUserInput = true
+	SkipFinalPrompt = true
+	blueprintDoc := &BlueprintConfig{
+		Variables: []Variable{
+			{Name: VarField{Value: "input1"}, Label: VarField{Value: "input1"}, Type: VarField{Value: "Input"}, Value: VarField{Value: "val1"}},
+			{Name: VarField{Value: "input2"}, Label: VarField{Value: "input2"}, Type: VarField{Value: "Input"}},
+			{Name: VarField{Value: "input3"}, Label: VarField{Value: "input3"}, Type: VarField{Value: "Input"}},
+			{Name: VarField{Value: "input5"}, Label: VarField{Value: "input5"}, Type: VarField{Value: "Input"}, Default: VarField{Value: "default5"}, OverrideDefault: VarField{Bool: true, Value: "true"}},
+			{Name: VarField{Value: "input6"}, Label: VarField{Value: "input6"}, Type: VarField{Value: "Input"}, Default: VarField{Value: "default6"}},
+		},
+	}
+	got, err := blueprintDoc.prepareTemplateData(
+		BlueprintParams{
+			AnswersFile:        GetTestTemplateDir("answer-input-2.yaml"),
+			AnswersMap:         map[string]string{"input1": "set1", "input2": "set2"},
+			OverrideDefaults:   map[string]string{"input5": "overdefault5", "input6": "overdefault6"},
+			StrictAnswers:      true,
+			UseDefaultsAsValue: true,
+		},
+		NewPreparedData(),
+		nil,
+	)
+	require.Nil(t, err)
+	// value field, then answers map, then answers file, then overridden defaults, then defaults
+	assert.Equal(t, map[string]interface{}{
+		"input1": "val1",
+		"input2": "set2",
+		"input3": "ans3",
+		"input5": "overdefault5",
+		"input6": "default6",
+	}, got.TemplateData)
+}
+
+func TestBlueprintYaml_prepareTemplateData_answerFiles(t *testing.T) {
+	SkipUserInput = true
+	SkipFinalPrompt = true
+	dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"cert.pem": "CERTDATA\n",
+		"name.txt": "demo",
+	})
+	defer cleanup()
+	variables := []Variable{
+		{Name: VarField{Value: "AppName"}, Label: VarField{Value: "AppName"}, Type: VarField{Value: "Input"}},
+		{Name: VarField{Value: "Cert"}, Label: VarField{Value: "Cert"}, Type: VarField{Value: "File"}},
+		{Name: VarField{Value: "Key"}, Label: VarField{Value: "Key"}, Type: VarField{Value: "SecretFile"}},
+	}
+
+	t.Run("should pass the path to File parameters and the content to the others", func(t *testing.T) {
+		blueprintDoc := &BlueprintConfig{Variables: variables}
+		got, err := blueprintDoc.prepareTemplateData(
+			BlueprintParams{
+				AnswerFiles: map[string]string{
+					"AppName": filepath.Join(dir, "name.txt"),
+					"Cert":    filepath.Join(dir, "cert.pem"),
+					"Key":     filepath.Join(dir, "cert.pem"),
+				},
+				StrictAnswers: true,
+			},
+			NewPreparedData(),
+			nil,
+		)
+		require.Nil(t, err)
+		assert.Equal(t, "demo", got.TemplateData["AppName"])
+		assert.Equal(t, "CERTDATA\n", got.TemplateData["Cert"])
+		assert.Equal(t, "CERTDATA\n", got.Secrets["Key"])
+	})
+
+	t.Run("should fail when the file of an answer cannot be read", func(t *testing.T) {
+		blueprintDoc := &BlueprintConfig{Variables: variables}
+		_, err := blueprintDoc.prepareTemplateData(
+			BlueprintParams{AnswerFiles: map[string]string{"AppName": filepath.Join(dir, "missing.txt")}, StrictAnswers: true},
+			NewPreparedData(),
+			nil,
+		)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "error reading the answer of variable [AppName]")
+	})
+}
+
+func TestGetValuesFromEnvironment(t *testing.T) {
+	assert.Equal(t, map[string]string{"AppName": "demo", "Password": "a=b", "Empty": ""}, GetValuesFromEnvironment("BLUEPRINT_ANSWER_", []string{
+		"BLUEPRINT_ANSWER_AppName=demo",
+		"BLUEPRINT_ANSWER_Password=a=b",
+		"BLUEPRINT_ANSWER_Empty=",
+		"BLUEPRINT_ANSWER_=ignored",
+		"OTHER_AppName=other",
+		"HOME=/root",
+	}))
+}
+
+func TestBlueprintYaml_prepareTemplateData_environmentAnswers(t *testing.T) {
+	SkipUserInput = true
+	SkipFinalPrompt = true
+	os.Setenv("TEST_ANSWER_input2", "env2")
+	os.Setenv("TEST_ANSWER_input3", "env3")
+	os.Setenv("TEST_ANSWER_input4", "not-a-number")
+	defer func() {
+		os.Unsetenv("TEST_ANSWER_input2")
+		os.Unsetenv("TEST_ANSWER_input3")
+		os.Unsetenv("TEST_ANSWER_input4")
+	}()
+	variables := []Variable{
+		{Name: VarField{Value: "input1"}, Label: VarField{Value: "input1"}, Type: VarField{Value: "Input"}},
+		{Name: VarField{Value: "input2"}, Label: VarField{Value: "input2"}, Type: VarField{Value: "Input"}},
+		{Name: VarField{Value: "input3"}, Label: VarField{Value: "input3"}, Type: VarField{Value: "SecretInput"}},
+	}
+
+	t.Run("should take answers from the environment between the answers map and the answers file", func(t *testing.T) {
+		blueprintDoc := &BlueprintConfig{Variables: variables}
+		got, err := blueprintDoc.prepareTemplateData(
+			BlueprintParams{
+				AnswersFile:      GetTestTemplateDir("answer-input-2.yaml"),
+				AnswersMap:       map[string]string{"input3": "set3"},
+				AnswersEnvPrefix: "TEST_ANSWER_",
+				StrictAnswers:    true,
+			},
+			NewPreparedData(),
+			nil,
+		)
+		require.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"input1": "ans1", "input2": "env2", "input3": "!value input3"}, got.TemplateData)
+		assert.Equal(t, map[string]interface{}{"input3": "set3"}, got.Secrets)
+	})
+
+	t.Run("should fail in strict mode when a variable is not in the environment", func(t *testing.T) {
+		blueprintDoc := &BlueprintConfig{Variables: variables}
+		_, err := blueprintDoc.prepareTemplateData(
+			BlueprintParams{AnswersEnvPrefix: "TEST_ANSWER_", StrictAnswers: true},
+			NewPreparedData(),
+			nil,
+		)
+		require.NotNil(t, err)
+		assert.Equal(t, "variable with name [input1] could not be found in answers file", err.Error())
+	})
+
+	t.Run("should validate the answers from the environment", func(t *testing.T) {
+		blueprintDoc := &BlueprintConfig{Variables: []Variable{
+			{
+				Name:     VarField{Value: "input4"},
+				Label:    VarField{Value: "input4"},
+				Type:     VarField{Value: "Input"},
+				Validate: VarField{Value: "regex('^[0-9]+$', input4)", Tag: tagExpressionV2},
+			},
+		}}
+		_, err := blueprintDoc.prepareTemplateData(
+			BlueprintParams{AnswersEnvPrefix: "TEST_ANSWER_", StrictAnswers: true},
+			NewPreparedData(),
+			nil,
+		)
+		require.NotNil(t, err)
+	})
+}
+
 func Test_findLabelValueFromOptions(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,207 @@
+package blueprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"github.com/xebialabs/yaml"
+)
+
+// ExtractValue maps a literal value of a project to the parameter it is replaced with
+type ExtractValue struct {
+	Parameter string
+	Literal   string
+}
+
+// ExtractParams holds the options of a blueprint extraction
+type ExtractParams struct {
+	SourceDir string
+	TargetDir string
+	Name      string
+	Values    []ExtractValue
+}
+
+// ExtractResult describes the blueprint written by ExtractBlueprint
+type ExtractResult struct {
+	Files       []string
+	Templates   []string
+	Occurrences map[string]int
+}
+
+// directories never copied to an extracted blueprint
+var extractIgnoredDirs = []string{".git"}
+
+// ExtractBlueprint turns the project in SourceDir into a blueprint in TargetDir.
+// The literal values are replaced with {{.Parameter}} placeholders, files containing them are renamed to .tmpl, and
+// a v2 blueprint definition with a parameter for each value and every file is written.
+func ExtractBlueprint(params ExtractParams) (*ExtractResult, error) {
+	if err := validateExtractParams(params); err != nil {
+		return nil, err
+	}
+
+	replacements := make([]string, 0, len(params.Values)*2+4)
+	literals := make([]ExtractValue, len(params.Values))
+	copy(literals, params.Values)
+	// longer literals first, so that a value containing another value is replaced as a whole
+	sort.SliceStable(literals, func(i, j int) bool { return len(literals[i].Literal) > len(literals[j].Literal) })
+	for _, value := range literals {
+		replacements = append(replacements, value.Literal, "{{."+value.Parameter+"}}")
+	}
+	// template delimiters already in the project are kept as they are
+	replacements = append(replacements, "{{", `{{"{{"}}`, "}}", `{{"}}"}}`)
+	replacer := strings.NewReplacer(replacements...)
+
+	result := &ExtractResult{Occurrences: make(map[string]int)}
+	err := filepath.Walk(params.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(params.SourceDir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if relPath != "." && util.IsStringInSlice(info.Name(), extractIgnoredDirs) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			util.Verbose("[extract] Skipping %s as it is not a regular file\n", path)
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		blueprintPath := filepath.ToSlash(relPath)
+		if !isBinaryContent(content) {
+			replaced := replacer.Replace(string(content))
+			found := false
+			for _, value := range params.Values {
+				if count := strings.Count(replaced, "{{."+value.Parameter+"}}"); count > 0 {
+					result.Occurrences[value.Parameter] += count
+					found = true
+				}
+			}
+			if found {
+				content = []byte(replaced)
+				blueprintPath += templateExtension
+				result.Templates = append(result.Templates, blueprintPath)
+			}
+		}
+
+		targetPath := filepath.Join(params.TargetDir, filepath.FromSlash(blueprintPath))
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(targetPath, content, info.Mode().Perm()); err != nil {
+			return err
+		}
+		util.Verbose("[extract] Wrote %s\n", targetPath)
+		result.Files = append(result.Files, blueprintPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	definition, err := yaml.Marshal(extractDefinition(params, result.Files))
+	if err != nil {
+		return nil, err
+	}
+	definitionPath := filepath.Join(params.TargetDir, repository.BlueprintMetadataFileName+repository.BlueprintMetadataFileExtensions[0])
+	if err := ioutil.WriteFile(definitionPath, definition, 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func validateExtractParams(params ExtractParams) error {
+	if info, err := os.Stat(params.SourceDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("project directory %s does not exist", params.SourceDir)
+	}
+	if definition := findDefinitionFile(params.SourceDir); definition != "" {
+		return fmt.Errorf("project directory %s already contains a blueprint definition %s", params.SourceDir, definition)
+	}
+	source, err := filepath.Abs(params.SourceDir)
+	if err != nil {
+		return err
+	}
+	target, err := filepath.Abs(params.TargetDir)
+	if err != nil {
+		return err
+	}
+	if target == source || strings.HasPrefix(target, source+string(os.PathSeparator)) {
+		return fmt.Errorf("blueprint directory %s must not be inside the project directory", params.TargetDir)
+	}
+	if files, err := ioutil.ReadDir(params.TargetDir); err == nil && len(files) > 0 {
+		return fmt.Errorf("blueprint directory %s already exists and is not empty", params.TargetDir)
+	}
+
+	if len(params.Values) == 0 {
+		return fmt.Errorf("at least one value to replace with a parameter is required")
+	}
+	parameters := make(map[string]bool)
+	literals := make(map[string]bool)
+	for _, value := range params.Values {
+		if !regExIdentifier.MatchString(value.Parameter) {
+			return fmt.Errorf("parameter name [%s] is not valid, it must start with a letter and contain only letters, digits and underscores", value.Parameter)
+		}
+		if value.Literal == "" {
+			return fmt.Errorf("value of parameter [%s] must not be empty", value.Parameter)
+		}
+		if parameters[value.Parameter] {
+			return fmt.Errorf("parameter [%s] is given more than once", value.Parameter)
+		}
+		if literals[value.Literal] {
+			return fmt.Errorf("value [%s] is mapped to more than one parameter", value.Literal)
+		}
+		parameters[value.Parameter] = true
+		literals[value.Literal] = true
+	}
+	return nil
+}
+
+func extractDefinition(params ExtractParams, files []string) yaml.MapSlice {
+	name := params.Name
+	if name == "" {
+		absPath, _ := filepath.Abs(params.SourceDir)
+		name = filepath.Base(absPath)
+	}
+
+	parameters := make([]yaml.MapSlice, 0, len(params.Values))
+	for _, value := range params.Values {
+		parameters = append(parameters, yaml.MapSlice{
+			{Key: "name", Value: value.Parameter},
+			{Key: "type", Value: TypeInput},
+			{Key: "prompt", Value: fmt.Sprintf("What is the value of %s?", value.Parameter)},
+			{Key: "default", Value: value.Literal},
+		})
+	}
+	fileEntries := make([]yaml.MapSlice, 0, len(files))
+	for _, file := range files {
+		fileEntries = append(fileEntries, yaml.MapSlice{{Key: "path", Value: file}})
+	}
+
+	return yaml.MapSlice{
+		{Key: "apiVersion", Value: models.BlueprintYamlFormatV2},
+		{Key: "kind", Value: "Blueprint"},
+		{Key: "metadata", Value: yaml.MapSlice{
+			{Key: "name", Value: name},
+			{Key: "description", Value: fmt.Sprintf("Blueprint extracted from project %s", name)},
+		}},
+		{Key: "spec", Value: yaml.MapSlice{
+			{Key: "parameters", Value: parameters},
+			{Key: "files", Value: fileEntries},
+		}},
+	}
+}
@@ -0,0 +1,83 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractBlueprint(t *testing.T) {
+	t.Run("should extract a blueprint that generates the project again", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"project/app.yaml":            "name: my-app\nimage: my-app-image:1.0\nregion: eu-west-1",
+			"project/helm/values.yaml":    "region: eu-west-1\nhost: {{ .Values.host }}",
+			"project/README.md":           "static readme",
+			"project/.git/HEAD":           "ref: refs/heads/master",
+			"project/xebialabs/empty.txt": "",
+		})
+		defer cleanup()
+		projectDir := filepath.Join(dir, "project")
+		repoDir := filepath.Join(dir, "repo")
+
+		result, err := ExtractBlueprint(ExtractParams{
+			SourceDir: projectDir,
+			TargetDir: filepath.Join(repoDir, "my-app"),
+			Values: []ExtractValue{
+				{Parameter: "AppName", Literal: "my-app"},
+				{Parameter: "Image", Literal: "my-app-image"},
+				{Parameter: "Region", Literal: "eu-west-1"},
+			},
+		})
+		require.Nil(t, err)
+		assert.Equal(t, []string{"README.md", "app.yaml.tmpl", "helm/values.yaml.tmpl", "xebialabs/empty.txt"}, result.Files)
+		assert.Equal(t, []string{"app.yaml.tmpl", "helm/values.yaml.tmpl"}, result.Templates)
+		assert.Equal(t, map[string]int{"AppName": 1, "Image": 1, "Region": 2}, result.Occurrences)
+		assert.Equal(t, "name: {{.AppName}}\nimage: {{.Image}}:1.0\nregion: {{.Region}}", GetFileContent(filepath.Join(repoDir, "my-app", "app.yaml.tmpl")))
+		_, err = os.Stat(filepath.Join(repoDir, "my-app", ".git"))
+		assert.True(t, os.IsNotExist(err))
+
+		blueprintContext, err := ConstructLocalBlueprintContext(repoDir)
+		require.Nil(t, err)
+		outputDir := filepath.Join(dir, "generated")
+		gb := &GeneratedBlueprint{BaseDir: outputDir, OutputDir: "xebialabs"}
+		_, _, err = InstantiateBlueprint(
+			BlueprintParams{TemplatePath: "my-app", AnswersMap: map[string]string{}, StrictAnswers: true, UseDefaultsAsValue: true},
+			blueprintContext, gb, nil,
+		)
+		require.Nil(t, err)
+		for _, file := range []string{"app.yaml", filepath.Join("helm", "values.yaml"), "README.md"} {
+			assert.Equal(t, GetFileContent(filepath.Join(projectDir, file)), GetFileContent(filepath.Join(outputDir, file)))
+		}
+	})
+
+	t.Run("should validate the parameters", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{"project/app.yaml": "name: my-app"})
+		defer cleanup()
+		projectDir := filepath.Join(dir, "project")
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "used.txt"), []byte("used"), 0644))
+
+		tests := []struct {
+			name      string
+			targetDir string
+			values    []ExtractValue
+			want      string
+		}{
+			{"no values", filepath.Join(dir, "bp"), nil, "at least one value to replace with a parameter is required"},
+			{"invalid parameter name", filepath.Join(dir, "bp"), []ExtractValue{{"App-Name", "my-app"}}, "parameter name [App-Name] is not valid, it must start with a letter and contain only letters, digits and underscores"},
+			{"duplicate values", filepath.Join(dir, "bp"), []ExtractValue{{"A", "my-app"}, {"B", "my-app"}}, "value [my-app] is mapped to more than one parameter"},
+			{"target inside project", filepath.Join(projectDir, "bp"), []ExtractValue{{"A", "my-app"}}, "blueprint directory " + filepath.Join(projectDir, "bp") + " must not be inside the project directory"},
+			{"target not empty", dir, []ExtractValue{{"A", "my-app"}}, "blueprint directory " + dir + " already exists and is not empty"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := ExtractBlueprint(ExtractParams{SourceDir: projectDir, TargetDir: tt.targetDir, Values: tt.values})
+				require.NotNil(t, err)
+				assert.Equal(t, tt.want, err.Error())
+			})
+		}
+	})
+}
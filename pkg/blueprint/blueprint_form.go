@@ -0,0 +1,218 @@
+package blueprint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+// BlueprintForm is a web form for the parameters of a composed blueprint. The blueprint is read once, the form is
+// evaluated again for every change of the answers so that conditions and validations are those of the CLI.
+type BlueprintForm struct {
+	TemplatePath string
+	master       *BlueprintConfig
+	docs         []*ComposedBlueprint
+}
+
+// FormState is the form of a blueprint for a set of answers
+type FormState struct {
+	Blueprint   string      `json:"blueprint"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Fields      []FormField `json:"fields"`
+	Valid       bool        `json:"valid"`
+
+	// answers holds the answer, or the default, of every visible field
+	answers map[string]string
+}
+
+// FormField is a parameter of the blueprint, fields are hidden when their promptIf condition is not met, when they
+// have a value, or when their blueprint is not included
+type FormField struct {
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	Prompt      string       `json:"prompt"`
+	Description string       `json:"description,omitempty"`
+	Default     interface{}  `json:"default"`
+	Options     []FormOption `json:"options,omitempty"`
+	Secret      bool         `json:"secret"`
+	Visible     bool         `json:"visible"`
+	Error       string       `json:"error,omitempty"`
+	Blueprint   string       `json:"blueprint"`
+}
+
+// FormOption is an option of a Select field
+type FormOption struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+}
+
+// NewBlueprintForm reads the composed blueprint of the active repository to build its form
+func (blueprintContext *BlueprintContext) NewBlueprintForm(templatePath string) (*BlueprintForm, error) {
+	blueprints, err := blueprintContext.initCurrentRepoClient()
+	if err != nil {
+		return nil, err
+	}
+	docs, master, err := getBlueprintConfig(blueprintContext, blueprints, templatePath, []VarField{{}}, "")
+	if err != nil {
+		return nil, err
+	}
+	return &BlueprintForm{TemplatePath: templatePath, master: master, docs: docs}, nil
+}
+
+// Evaluate returns the fields of the form for the given answers, in question order. Blueprints are included, and
+// fields are shown, on the same conditions as when the questions are asked on the command line. Fields without an
+// answer get their default value.
+func (form *BlueprintForm) Evaluate(answers map[string]string) (*FormState, error) {
+	state := &FormState{
+		Blueprint:   form.TemplatePath,
+		Name:        form.master.Metadata.Name,
+		Description: form.master.Metadata.Description,
+		Fields:      make([]FormField, 0),
+		Valid:       true,
+		answers:     make(map[string]string),
+	}
+	data := NewPreparedData()
+	var skippedBlueprints []string
+	for _, blueprintDoc := range form.docs {
+		included := !util.IsStringInSlice(blueprintDoc.Parent, skippedBlueprints)
+		if included {
+			var err error
+			included, err = evaluateAndSkipIfDependsOnIsFalse(blueprintDoc.DependsOn, data, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !included {
+			skippedBlueprints = append(skippedBlueprints, blueprintDoc.Name)
+		}
+
+		for _, variable := range blueprintDoc.BlueprintConfig.Variables {
+			// the variable is a copy, expressions are processed again on every evaluation
+			field, err := state.evaluateField(variable, answers, data, included)
+			if err != nil {
+				return nil, err
+			}
+			field.Blueprint = blueprintDoc.Name
+			state.Fields = append(state.Fields, field)
+		}
+	}
+	return state, nil
+}
+
+func (state *FormState) evaluateField(variable Variable, answers map[string]string, data *PreparedData, included bool) (FormField, error) {
+	variable.ProcessExpression(data.TemplateData, nil)
+	defaultVal := variable.GetDefaultVal()
+	field := FormField{
+		Name:        variable.Name.Value,
+		Type:        variable.Type.Value,
+		Prompt:      prepareQuestionText(variable.Prompt.Value, fmt.Sprintf("What is the value of %s?", variable.Name.Value)),
+		Description: variable.GetHelpText(),
+		Default:     defaultVal,
+		Secret:      IsSecretType(variable.Type.Value),
+	}
+	if field.Secret && !variable.RevealOnSummary.Bool {
+		// default secrets are used when the field is left empty, they are not sent to the browser
+		field.Default = ""
+	}
+	if variable.Type.Value == TypeConfirm {
+		field.Default = variable.Default.Bool || fmt.Sprint(defaultVal) == "true"
+	}
+	if variable.Type.Value == TypeSelect {
+		for _, option := range variable.GetOptions(data.TemplateData, false, nil) {
+			field.Options = append(field.Options, FormOption{Value: option, Label: findOptionLabel(option, variable.Options)})
+		}
+	}
+	if !included {
+		return field, nil
+	}
+
+	if !util.IsStringEmpty(variable.DependsOn.Value) {
+		dependsOnVal, err := ParseDependsOnValue(variable.DependsOn, data.TemplateData)
+		if err != nil {
+			return field, err
+		}
+		if skipQuestionOnCondition(&variable, variable.DependsOn.Value, dependsOnVal, data, defaultVal, variable.DependsOn.InvertBool) {
+			return field, nil
+		}
+	}
+	if variable.Value.Value != "" {
+		if parsedVal := variable.GetValueFieldVal(); parsedVal != nil && parsedVal != "" {
+			if variable.Type.Value == TypeConfirm {
+				parsedVal = variable.Value.Bool
+			}
+			saveItemToTemplateDataMap(&variable, data, parsedVal)
+			return field, nil
+		}
+	}
+
+	field.Visible = true
+	answer, ok := answers[field.Name]
+	if !ok || (field.Secret && answer == "") {
+		answer = fmt.Sprint(defaultVal)
+		if variable.Type.Value == TypeConfirm {
+			answer = fmt.Sprint(field.Default)
+		}
+		// like on the command line, the first option is selected when there is no default
+		if variable.Type.Value == TypeSelect && findFormOption(answer, field.Options) == nil && len(field.Options) > 0 {
+			answer = field.Options[0].Value
+		}
+	}
+	value, err := variable.validateFormAnswer(answer, field.Options, data.TemplateData)
+	if err != nil {
+		field.Error = err.Error()
+		state.Valid = false
+	}
+	state.answers[field.Name] = answer
+	saveItemToTemplateDataMap(&variable, data, value)
+	return field, nil
+}
+
+// validateFormAnswer checks an answer of the form like the answer of a question, file answers are the file contents
+func (variable *Variable) validateFormAnswer(answer string, options []FormOption, parameters map[string]interface{}) (interface{}, error) {
+	validateExpr, err := variable.GetValidateExpr()
+	if err != nil {
+		return nil, fmt.Errorf("error getting validation expression: %s", err.Error())
+	}
+	switch variable.Type.Value {
+	case TypeConfirm:
+		if answer == "" {
+			return false, nil
+		}
+		return strconv.ParseBool(answer)
+	case TypeFile, TypeSecretFile:
+		if answer == "" {
+			return nil, fmt.Errorf("a file is required")
+		}
+		return answer, nil
+	case TypeSelect:
+		if findFormOption(answer, options) == nil {
+			return nil, fmt.Errorf("answer [%s] is not one of the available options", answer)
+		}
+	}
+	allowEmpty := variable.AllowEmpty.Bool || IsSecretType(variable.Type.Value)
+	if err := validatePrompt(variable.Name.Value, validateExpr, allowEmpty, parameters, nil)(answer); err != nil {
+		return nil, err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+func findFormOption(value string, options []FormOption) *FormOption {
+	for i, option := range options {
+		if option.Value == value {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+func findOptionLabel(value string, options []VarField) string {
+	for _, option := range options {
+		if option.Value == value {
+			return option.Label
+		}
+	}
+	return ""
+}
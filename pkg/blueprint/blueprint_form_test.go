@@ -0,0 +1,156 @@
+package blueprint
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getFormTestBlueprintContext(t *testing.T) *BlueprintContext {
+	blueprintContext, err := ConstructLocalBlueprintContext(GetTestTemplateDir("form-repo"))
+	require.Nil(t, err)
+	return blueprintContext
+}
+
+func formFields(state *FormState) map[string]FormField {
+	fields := make(map[string]FormField)
+	for _, field := range state.Fields {
+		fields[field.Name] = field
+	}
+	return fields
+}
+
+func TestBlueprintForm_Evaluate(t *testing.T) {
+	blueprintContext := getFormTestBlueprintContext(t)
+	form, err := blueprintContext.NewBlueprintForm("app")
+	require.Nil(t, err)
+
+	t.Run("should build the fields with their defaults", func(t *testing.T) {
+		state, err := form.Evaluate(nil)
+		require.Nil(t, err)
+		assert.False(t, state.Valid)
+		var names []string
+		for _, field := range state.Fields {
+			names = append(names, field.Name)
+		}
+		assert.Equal(t, []string{"AppName", "Region", "UseDb", "DbPassword", "Cert", "Static", "Size"}, names)
+
+		fields := formFields(state)
+		assert.Equal(t, "Value is required", fields["AppName"].Error)
+		assert.Equal(t, []FormOption{{Value: "eu", Label: "Europe"}, {Value: "us"}}, fields["Region"].Options)
+		assert.Empty(t, fields["Region"].Error)
+		assert.Equal(t, false, fields["UseDb"].Default)
+		assert.Equal(t, "a file is required", fields["Cert"].Error)
+		assert.True(t, fields["DbPassword"].Secret)
+		assert.False(t, fields["DbPassword"].Visible)
+		assert.False(t, fields["Static"].Visible)
+		assert.False(t, fields["Size"].Visible)
+	})
+
+	t.Run("should show fields on their conditions and validate answers", func(t *testing.T) {
+		state, err := form.Evaluate(map[string]string{"AppName": "Demo!", "Region": "asia", "UseDb": "true", "Cert": "cert"})
+		require.Nil(t, err)
+		assert.False(t, state.Valid)
+		fields := formFields(state)
+		assert.Equal(t, "validation [regex('^[a-z]+$', AppName)] failed with value [Demo!]", fields["AppName"].Error)
+		assert.Equal(t, "answer [asia] is not one of the available options", fields["Region"].Error)
+		assert.True(t, fields["DbPassword"].Visible)
+		assert.True(t, fields["Size"].Visible)
+		assert.Equal(t, "small", fields["Size"].Default)
+
+		state, err = form.Evaluate(map[string]string{"AppName": "demo", "Region": "us", "UseDb": "true", "Cert": "cert"})
+		require.Nil(t, err)
+		assert.True(t, state.Valid)
+		assert.Equal(t, map[string]string{"AppName": "demo", "Region": "us", "UseDb": "true", "DbPassword": "", "Cert": "cert", "Size": "small"}, state.answers)
+	})
+}
+
+func TestBlueprintUI(t *testing.T) {
+	blueprintContext := getFormTestBlueprintContext(t)
+	dir, err := ioutil.TempDir("", "blueprintui")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	outputDir := filepath.Join(dir, "project")
+	ui, err := NewBlueprintUI(blueprintContext, "app", outputDir)
+	require.Nil(t, err)
+	server := httptest.NewServer(ui)
+	defer server.Close()
+
+	t.Run("should serve the page", func(t *testing.T) {
+		response, err := http.Get(server.URL)
+		require.Nil(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", response.Header.Get("Content-Type"))
+	})
+
+	t.Run("should reject requests from other web pages", func(t *testing.T) {
+		response, err := http.Post(server.URL+"/api/generate", "text/plain", strings.NewReader(`{"answers": {}}`))
+		require.Nil(t, err)
+		assert.Equal(t, http.StatusUnsupportedMediaType, response.StatusCode)
+		response.Body.Close()
+
+		for _, header := range []struct{ name, value string }{{"Origin", "http://example.com"}, {"Host", "example.com"}} {
+			request, err := http.NewRequest(http.MethodPost, server.URL+"/api/generate", strings.NewReader(`{"answers": {}}`))
+			require.Nil(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			if header.name == "Host" {
+				request.Host = header.value
+			} else {
+				request.Header.Set(header.name, header.value)
+			}
+			response, err := http.DefaultClient.Do(request)
+			require.Nil(t, err)
+			assert.Equal(t, http.StatusForbidden, response.StatusCode, header.name)
+			response.Body.Close()
+		}
+		assert.False(t, exists(outputDir))
+	})
+
+	t.Run("should return the form with the errors of the answers", func(t *testing.T) {
+		response := postJSON(t, server.URL+"/api/generate", `{"answers": {"AppName": "demo"}}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+		var state FormState
+		decodeJSON(t, response, &state)
+		assert.False(t, state.Valid)
+		assert.Equal(t, "a file is required", formFields(&state)["Cert"].Error)
+	})
+
+	t.Run("should generate the blueprint with uploaded files", func(t *testing.T) {
+		response := postJSON(t, server.URL+"/api/generate", `{"answers": {"AppName": "demo", "UseDb": "true", "DbPassword": "secret", "Cert": "my cert"}}`)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		var result generateResponse
+		decodeJSON(t, response, &result)
+		assert.Equal(t, outputDir, result.OutputDir)
+		assert.Equal(t, "demo eu true my cert", GetFileContent(filepath.Join(outputDir, "app.yaml")))
+		assert.Equal(t, "small", GetFileContent(filepath.Join(outputDir, "db.yaml")))
+		assert.Contains(t, GetFileContent(filepath.Join(outputDir, "xebialabs", "secrets.xlvals")), "DbPassword = secret")
+		assert.Len(t, result.Result.Files, 5)
+	})
+
+	t.Run("should only overwrite existing files when asked to", func(t *testing.T) {
+		body := map[string]interface{}{"answers": map[string]string{"AppName": "other", "Cert": "my cert"}}
+		content, err := json.Marshal(body)
+		require.Nil(t, err)
+		response := postJSON(t, server.URL+"/api/generate", string(content))
+		assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+		response.Body.Close()
+		assert.Equal(t, "demo eu true my cert", GetFileContent(filepath.Join(outputDir, "app.yaml")))
+
+		body["overwrite"] = true
+		content, err = json.Marshal(body)
+		require.Nil(t, err)
+		response = postJSON(t, server.URL+"/api/generate", string(content))
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		response.Body.Close()
+		assert.Equal(t, "other eu false my cert", GetFileContent(filepath.Join(outputDir, "app.yaml")))
+	})
+}
@@ -1,21 +1,132 @@
 package blueprint
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
+	"strconv"
+	"sync"
 
-	"github.com/xebialabs/blueprint-cli/pkg/models"
 	"github.com/xebialabs/blueprint-cli/pkg/util"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+)
+
+// statuses of the blueprint output files
+const (
+	FileStatusCreated     = "created"
+	FileStatusOverwritten = "overwritten"
+	FileStatusUnchanged   = "unchanged"
+	FileStatusSkipped     = "skipped"
+	FileStatusKept        = "kept"
 )
 
 // GeneratedBlueprint keeps track of all files and directories that were generated as part of the blueprint process.
+// Files are generated in BaseDir, or in the current directory when it is empty, and values and secrets are written
+// to OutputDir inside it. In dry-run mode nothing is written and the files are only recorded with their content.
+// Blueprint output files are written to a staging directory first and only replace the files of BaseDir on Commit. The
+// replaced files are backed up until Finish is called, so that a failed or interrupted generation can be rolled back
+// with Cleanup, even after the commit. Staging directories left by a crashed generation are removed by the next one.
 type GeneratedBlueprint struct {
+	BaseDir        string
 	OutputDir      string
+	DryRun         bool
 	GeneratedFiles []string
+	Files          []FileRecord
+	Manifest       *BlueprintManifest
+	Composition    []BlueprintInclusion
+
+	// existing files are overwritten when no conflict policy is set
+	conflictPolicy string
+	surveyOpts     []survey.AskOpt
+	staging        *stagingArea
+}
+
+const (
+	stagingDirPrefix = ".blueprint-staging-"
+	backupSuffix     = ".orig"
+)
+
+var errStagingAborted = errors.New("blueprint generation was aborted")
+
+// stagingArea holds the output files until they are committed and the backups of the replaced files until the
+// generation is finished, the lock is shared with Cleanup so that an interrupt never sees a half committed file
+type stagingArea struct {
+	sync.Mutex
+	dir      string
+	files    []*stagedFile
+	finished bool
+	aborted  bool
+}
+
+// stagedFile is an output file written in the staging directory, or removed when committed
+type stagedFile struct {
+	path    string
+	staged  string
+	backup  string
+	removed bool
+	moved   bool
+}
+
+// getFile returns the staged file for an output file, files written twice are staged once
+func (staging *stagingArea) getFile(fileName string) *stagedFile {
+	for _, file := range staging.files {
+		if file.path == fileName {
+			return file
+		}
+	}
+	file := &stagedFile{path: fileName, staged: filepath.Join(staging.dir, strconv.Itoa(len(staging.files)))}
+	staging.files = append(staging.files, file)
+	return file
+}
+
+// FileRecord is a blueprint output file, relative to the base directory, with what happened to it, or would happen to
+// it in dry-run mode
+type FileRecord struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	Size    int    `json:"size"`
+	Reason  string `json:"reason,omitempty"`
+	Content string `json:"-"`
+}
+
+// OutputPath returns the path of a blueprint output file, relative to the current directory
+func (generatedBlueprint *GeneratedBlueprint) OutputPath(fileName string) string {
+	return filepath.Join(generatedBlueprint.BaseDir, fileName)
+}
+
+// recordFile registers an output file, the status is based on the file found in the base directory before writing
+func (generatedBlueprint *GeneratedBlueprint) recordFile(fileName string, data string) {
+	record := FileRecord{Path: fileName, Status: FileStatusCreated, Size: len(data)}
+	filePath := generatedBlueprint.OutputPath(fileName)
+	if exists(filePath) {
+		record.Status = FileStatusOverwritten
+		if existing, err := ioutil.ReadFile(filePath); err == nil && string(existing) == data {
+			record.Status = FileStatusUnchanged
+		}
+	}
+	record.Content = data
+	generatedBlueprint.Files = append(generatedBlueprint.Files, record)
+}
+
+// recordKeptFile registers an output file that is not written because the existing file is kept
+func (generatedBlueprint *GeneratedBlueprint) recordKeptFile(fileName string, data string) {
+	generatedBlueprint.Files = append(generatedBlueprint.Files, FileRecord{
+		Path:    fileName,
+		Status:  FileStatusKept,
+		Size:    len(data),
+		Reason:  "the existing file is kept",
+		Content: data,
+	})
+}
+
+// recordSkippedFile registers an output file that is not written because of its writeIf condition, or because its
+// blueprint is not included
+func (generatedBlueprint *GeneratedBlueprint) recordSkippedFile(fileName string, reason string) {
+	generatedBlueprint.Files = append(generatedBlueprint.Files, FileRecord{Path: fileName, Status: FileStatusSkipped, Reason: reason})
 }
 
 // createDirectoryIfNeeded will create a Directory if it does not exist and add it to the GeneratedBlueprint context object.
@@ -61,8 +172,199 @@ func (generatedBlueprint *GeneratedBlueprint) GetOutputFile(fileName string) (*o
 	return file, nil
 }
 
-// Cleanup will cleanup all generated blueprint files
+// createStagedFile returns a newly created (or truncated) file in the staging directory, it is moved to fileName when
+// the blueprint output is committed.
+func (generatedBlueprint *GeneratedBlueprint) createStagedFile(fileName string) (*os.File, error) {
+	if err := generatedBlueprint.initStaging(); err != nil {
+		return nil, err
+	}
+	staging := generatedBlueprint.staging
+	staging.Lock()
+	defer staging.Unlock()
+	if staging.aborted {
+		return nil, errStagingAborted
+	}
+	file := staging.getFile(fileName)
+	file.removed = false
+	util.Verbose("[file] Staging file %s\n", fileName)
+	return os.Create(file.staged)
+}
+
+// removeFile schedules the removal of an existing file when the blueprint output is committed
+func (generatedBlueprint *GeneratedBlueprint) removeFile(fileName string) error {
+	if err := generatedBlueprint.initStaging(); err != nil {
+		return err
+	}
+	staging := generatedBlueprint.staging
+	staging.Lock()
+	defer staging.Unlock()
+	if staging.aborted {
+		return errStagingAborted
+	}
+	staging.getFile(fileName).removed = true
+	return nil
+}
+
+// initStaging creates the staging directory in the base directory, so that staged files can be renamed in place
+func (generatedBlueprint *GeneratedBlueprint) initStaging() error {
+	if generatedBlueprint.staging != nil {
+		return nil
+	}
+	baseDir := generatedBlueprint.OutputPath("")
+	if baseDir == "" {
+		baseDir = "."
+	}
+	if err := generatedBlueprint.createDirectoryIfNeeded(baseDir); err != nil {
+		return err
+	}
+	if err := removeStaleStagingDirs(baseDir); err != nil {
+		return err
+	}
+	dir, err := ioutil.TempDir(baseDir, stagingDirPrefix)
+	if err != nil {
+		return err
+	}
+	util.Verbose("[file] Staging blueprint output files in %s\n", dir)
+	generatedBlueprint.staging = &stagingArea{dir: dir}
+	return nil
+}
+
+// removeStaleStagingDirs removes the staging directories of generations that did not finish, the backups they contain
+// cannot be restored anymore
+func removeStaleStagingDirs(baseDir string) error {
+	dirs, err := filepath.Glob(filepath.Join(baseDir, stagingDirPrefix+"*"))
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if isDir, _ := isDirectory(dir); !isDir {
+			continue
+		}
+		util.Info("Removing staging directory %s left by a previous blueprint generation\n", dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit moves the staged files in place, replaced files are backed up until Finish is called.
+// When a file cannot be moved, all changes are rolled back.
+func (generatedBlueprint *GeneratedBlueprint) Commit() error {
+	staging := generatedBlueprint.staging
+	if staging == nil {
+		return nil
+	}
+	for i := range staging.files {
+		if err := generatedBlueprint.commitFile(staging, i); err != nil {
+			if cleanupErr := generatedBlueprint.Cleanup(); cleanupErr != nil {
+				util.Error("Error while rolling back blueprint output files: %s\n", cleanupErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Finish removes the backups of the files replaced by Commit once the command has succeeded, after that Cleanup can
+// only remove the created files and directories.
+func (generatedBlueprint *GeneratedBlueprint) Finish() error {
+	staging := generatedBlueprint.staging
+	if staging == nil {
+		return nil
+	}
+	staging.Lock()
+	defer staging.Unlock()
+	if staging.aborted {
+		return errStagingAborted
+	}
+	staging.finished = true
+	return os.RemoveAll(staging.dir)
+}
+
+func (generatedBlueprint *GeneratedBlueprint) commitFile(staging *stagingArea, i int) error {
+	staging.Lock()
+	defer staging.Unlock()
+	if staging.aborted {
+		return errStagingAborted
+	}
+	file := staging.files[i]
+
+	info, err := os.Stat(file.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	existed := err == nil
+	if !existed && file.removed {
+		return nil
+	}
+	if existed {
+		if !file.removed {
+			// keep the permissions of the replaced file
+			if err := os.Chmod(file.staged, info.Mode()); err != nil {
+				return err
+			}
+		}
+		backup := file.staged + backupSuffix
+		if err := os.Rename(file.path, backup); err != nil {
+			return err
+		}
+		file.backup = backup
+	}
+	if file.removed {
+		util.Verbose("[file] Removed file %s\n", file.path)
+		return nil
+	}
+
+	if err := generatedBlueprint.createDirectoryIfNeeded(filepath.Dir(file.path)); err != nil {
+		return err
+	}
+	if err := os.Rename(file.staged, file.path); err != nil {
+		return err
+	}
+	file.moved = true
+	if !existed {
+		generatedBlueprint.GeneratedFiles = append(generatedBlueprint.GeneratedFiles, file.path)
+	}
+	return nil
+}
+
+// rollback restores the files replaced or removed by a commit, finished or not, and removes the staging directory
+func (staging *stagingArea) rollback() error {
+	for i := len(staging.files) - 1; i >= 0; i-- {
+		file := staging.files[i]
+		if file.backup != "" {
+			util.Verbose("[file] Restoring file %s\n", file.path)
+			if err := os.Rename(file.backup, file.path); err != nil {
+				return err
+			}
+			file.backup = ""
+		} else if file.moved {
+			if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		file.moved = false
+	}
+	return os.RemoveAll(staging.dir)
+}
+
+// Cleanup will cleanup all generated blueprint files.
+// Until Finish is called the files are restored to their prior state, whether the blueprint output is committed or not.
+// Once finished the replaced files cannot be restored anymore and only the created files and directories are removed.
 func (generatedBlueprint *GeneratedBlueprint) Cleanup(filesSkipped ...string) error {
+	if staging := generatedBlueprint.staging; staging != nil {
+		staging.Lock()
+		staging.aborted = true
+		if !staging.finished {
+			if err := staging.rollback(); err != nil {
+				staging.Unlock()
+				return err
+			}
+		}
+		staging.Unlock()
+	}
+
 	var directories []string
 
 	// Clean all files first
@@ -80,8 +382,6 @@ func (generatedBlueprint *GeneratedBlueprint) Cleanup(filesSkipped ...string) er
 	// Reverse the directories
 	sort.Sort(sort.Reverse(sort.StringSlice(directories)))
 
-	xebialabsDir := ""
-
 	for _, dir := range directories {
 		util.Verbose("[file] Removing directory %s\n", dir)
 		if util.PathExists(dir, true) {
@@ -89,21 +389,9 @@ func (generatedBlueprint *GeneratedBlueprint) Cleanup(filesSkipped ...string) er
 				if err := os.Remove(dir); err != nil {
 					return err
 				}
-			} else {
-				if strings.HasSuffix(dir, models.BlueprintOutputDir) {
-					xebialabsDir = dir
-				}
 			}
 		}
 	}
-
-	// Manually remove the xebialabs directory
-	if xebialabsDir != "" {
-		if err := os.Remove(models.BlueprintOutputDir); err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
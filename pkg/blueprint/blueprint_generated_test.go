@@ -92,3 +92,127 @@ func TestCreateDirectoryIfNeeded(t *testing.T) {
 		})
 	}
 }
+
+func TestGeneratedBlueprintRecordsFileStatus(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "recordTest")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	existing := filepath.Join(tmpDir, "existing.yaml")
+	require.Nil(t, ioutil.WriteFile(existing, []byte("foo"), 0644))
+
+	gb := GeneratedBlueprint{DryRun: true}
+	gb.recordFile(filepath.Join(tmpDir, "new.yaml"), "bar")
+	gb.recordFile(existing, "foo")
+	gb.recordFile(existing, "changed")
+	gb.recordSkippedFile(filepath.Join(tmpDir, "skipped.yaml"), "writeIf condition [!expr false] is not met")
+
+	assert.Equal(t, []FileRecord{
+		{Path: filepath.Join(tmpDir, "new.yaml"), Status: FileStatusCreated, Size: 3, Content: "bar"},
+		{Path: existing, Status: FileStatusUnchanged, Size: 3, Content: "foo"},
+		{Path: existing, Status: FileStatusOverwritten, Size: 7, Content: "changed"},
+		{Path: filepath.Join(tmpDir, "skipped.yaml"), Status: FileStatusSkipped, Reason: "writeIf condition [!expr false] is not met"},
+	}, gb.Files)
+}
+
+func writeStagedTestFile(t *testing.T, gb *GeneratedBlueprint, fileName string, data string) {
+	file, err := gb.createStagedFile(fileName)
+	require.Nil(t, err)
+	_, err = file.WriteString(data)
+	require.Nil(t, err)
+	require.Nil(t, file.Close())
+}
+
+func stagingDirs(t *testing.T, dir string) []string {
+	dirs, err := filepath.Glob(filepath.Join(dir, stagingDirPrefix+"*"))
+	require.Nil(t, err)
+	return dirs
+}
+
+func TestGeneratedBlueprintStagesFilesUntilCommit(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "stagingTest")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+	existing := filepath.Join(tmpDir, "existing.yaml")
+	removed := filepath.Join(tmpDir, "removed.yaml")
+	require.Nil(t, ioutil.WriteFile(existing, []byte("existing"), 0600))
+	require.Nil(t, ioutil.WriteFile(removed, []byte("removed"), 0644))
+
+	t.Run("should restore the prior state on cleanup", func(t *testing.T) {
+		gb := GeneratedBlueprint{BaseDir: tmpDir}
+		writeStagedTestFile(t, &gb, existing, "generated")
+		writeStagedTestFile(t, &gb, filepath.Join(tmpDir, "new", "new.yaml"), "generated")
+		require.Nil(t, gb.removeFile(removed))
+
+		assert.Equal(t, "existing", GetFileContent(existing))
+		assert.False(t, exists(filepath.Join(tmpDir, "new")))
+		assert.Len(t, stagingDirs(t, tmpDir), 1)
+
+		require.Nil(t, gb.Cleanup())
+		assert.Equal(t, "existing", GetFileContent(existing))
+		assert.Equal(t, "removed", GetFileContent(removed))
+		assert.False(t, exists(filepath.Join(tmpDir, "new")))
+		assert.Empty(t, stagingDirs(t, tmpDir))
+
+		_, err := gb.createStagedFile(existing)
+		assert.Equal(t, errStagingAborted, err)
+	})
+
+	t.Run("should roll back when a file cannot be committed", func(t *testing.T) {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(tmpDir, "notadir"), []byte(""), 0644))
+		defer os.Remove(filepath.Join(tmpDir, "notadir"))
+
+		gb := GeneratedBlueprint{BaseDir: tmpDir}
+		writeStagedTestFile(t, &gb, existing, "generated")
+		require.Nil(t, gb.removeFile(removed))
+		writeStagedTestFile(t, &gb, filepath.Join(tmpDir, "created.yaml"), "generated")
+		writeStagedTestFile(t, &gb, filepath.Join(tmpDir, "notadir", "file.yaml"), "generated")
+
+		err := gb.Commit()
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "not a directory")
+		assert.Equal(t, "existing", GetFileContent(existing))
+		assert.Equal(t, "removed", GetFileContent(removed))
+		assert.False(t, exists(filepath.Join(tmpDir, "created.yaml")))
+		assert.Empty(t, stagingDirs(t, tmpDir))
+	})
+
+	t.Run("should replace the files on commit and restore them until finished", func(t *testing.T) {
+		gb := GeneratedBlueprint{BaseDir: tmpDir}
+		writeStagedTestFile(t, &gb, existing, "generated")
+		writeStagedTestFile(t, &gb, filepath.Join(tmpDir, "new", "new.yaml"), "generated")
+		require.Nil(t, gb.removeFile(removed))
+
+		require.Nil(t, gb.Commit())
+		assert.Equal(t, "generated", GetFileContent(existing))
+		assert.Equal(t, "generated", GetFileContent(filepath.Join(tmpDir, "new", "new.yaml")))
+		assert.False(t, exists(removed))
+		info, err := os.Stat(existing)
+		require.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		assert.NotContains(t, gb.GeneratedFiles, existing)
+
+		// the backups are kept until the generation is finished
+		assert.Len(t, stagingDirs(t, tmpDir), 1)
+		require.Nil(t, gb.Cleanup())
+		assert.Equal(t, "existing", GetFileContent(existing))
+		assert.Equal(t, "removed", GetFileContent(removed))
+		assert.False(t, exists(filepath.Join(tmpDir, "new")))
+		assert.Empty(t, stagingDirs(t, tmpDir))
+	})
+
+	t.Run("should remove the backups when finished", func(t *testing.T) {
+		gb := GeneratedBlueprint{BaseDir: tmpDir}
+		writeStagedTestFile(t, &gb, existing, "generated")
+		writeStagedTestFile(t, &gb, filepath.Join(tmpDir, "new", "new.yaml"), "generated")
+
+		require.Nil(t, gb.Commit())
+		require.Nil(t, gb.Finish())
+		assert.Empty(t, stagingDirs(t, tmpDir))
+
+		// created files are removed by a cleanup after the generation is finished
+		require.Nil(t, gb.Cleanup())
+		assert.False(t, exists(filepath.Join(tmpDir, "new")))
+		assert.Equal(t, "generated", GetFileContent(existing))
+	})
+}
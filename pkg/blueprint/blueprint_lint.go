@@ -0,0 +1,385 @@
+package blueprint
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Knetic/govaluate"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/yaml"
+)
+
+// lint severities
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+// lint rules
+const (
+	lintRuleSchema           = "schema"
+	lintRuleParameter        = "parameter"
+	lintRuleDuplicate        = "duplicate-parameter"
+	lintRuleUndefinedRef     = "undefined-reference"
+	lintRuleExpression       = "invalid-expression"
+	lintRuleSelectDefault    = "select-default"
+	lintRuleFilePath         = "file-path"
+	lintRuleUnlistedFile     = "unlisted-file"
+	lintRuleMissingFile      = "missing-file"
+	lintRuleTemplate         = "template"
+	lintRuleMissingInclude   = "missing-include"
+	lintRuleInvalidInclude   = "invalid-include"
+	lintRuleUnknownOverride  = "unknown-override"
+	lintRuleDeprecatedSchema = "deprecated-schema"
+)
+
+// LintIssue is a single problem found in a blueprint
+type LintIssue struct {
+	Blueprint string `json:"blueprint"`
+	File      string `json:"file"`
+	Severity  string `json:"severity"`
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+}
+
+type blueprintLinter struct {
+	blueprintContext *BlueprintContext
+	blueprints       map[string]*models.BlueprintRemote
+	blueprintDir     string
+	definitionFile   string
+	issues           []LintIssue
+}
+
+// LintBlueprints checks every blueprint found in the given local directory and returns all problems found,
+// included blueprints are resolved against the active repository of the context
+func (blueprintContext *BlueprintContext) LintBlueprints(dir string) ([]LintIssue, error) {
+	blueprintDirs, err := findBlueprintDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(blueprintDirs) == 0 {
+		return nil, fmt.Errorf("no blueprint definition file found in %s", dir)
+	}
+
+	blueprints, err := blueprintContext.initCurrentRepoClient()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]LintIssue, 0)
+	for _, blueprintDir := range blueprintDirs {
+		linter := blueprintLinter{
+			blueprintContext: blueprintContext,
+			blueprints:       blueprints,
+			blueprintDir:     blueprintDir,
+		}
+		linter.lint()
+		issues = append(issues, linter.issues...)
+	}
+	return issues, nil
+}
+
+// findBlueprintDirs returns the given directory if it is a blueprint, or all blueprint directories below it otherwise
+func findBlueprintDirs(dir string) ([]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	if findDefinitionFile(dir) != "" {
+		return []string{dir}, nil
+	}
+
+	var blueprintDirs []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && repository.CheckIfBlueprintDefinitionFile(info.Name()) {
+			blueprintDirs = append(blueprintDirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	sort.Strings(blueprintDirs)
+	return blueprintDirs, err
+}
+
+func findDefinitionFile(dir string) string {
+	for _, ext := range repository.BlueprintMetadataFileExtensions {
+		fileName := repository.BlueprintMetadataFileName + ext
+		if info, err := os.Stat(filepath.Join(dir, fileName)); err == nil && !info.IsDir() {
+			return fileName
+		}
+	}
+	return ""
+}
+
+func (linter *blueprintLinter) addIssue(file string, severity string, rule string, format string, a ...interface{}) {
+	linter.issues = append(linter.issues, LintIssue{
+		Blueprint: linter.blueprintDir,
+		File:      file,
+		Severity:  severity,
+		Rule:      rule,
+		Message:   fmt.Sprintf(format, a...),
+	})
+}
+
+func (linter *blueprintLinter) addError(rule string, format string, a ...interface{}) {
+	linter.addIssue(linter.definitionFile, LintSeverityError, rule, format, a...)
+}
+
+func (linter *blueprintLinter) lint() {
+	linter.definitionFile = findDefinitionFile(linter.blueprintDir)
+	ymlContent, err := ioutil.ReadFile(filepath.Join(linter.blueprintDir, linter.definitionFile))
+	if err != nil {
+		linter.addError(lintRuleSchema, "%s", err)
+		return
+	}
+
+	versionDoc := struct {
+		ApiVersion string `yaml:"apiVersion"`
+	}{}
+	if err := yaml.NewDecoder(bytes.NewReader(ymlContent)).Decode(&versionDoc); err != nil {
+		linter.addError(lintRuleSchema, "%s", err)
+		return
+	}
+	if versionDoc.ApiVersion == models.BlueprintYamlFormatV1 {
+		linter.lintV1(ymlContent)
+		return
+	}
+	linter.lintV2(ymlContent)
+}
+
+// v1 documents are checked as a whole since the v1 parser converts them on the fly
+func (linter *blueprintLinter) lintV1(ymlContent []byte) {
+	linter.addIssue(linter.definitionFile, LintSeverityWarning, lintRuleDeprecatedSchema, "apiVersion %s is deprecated, use %s instead", models.BlueprintYamlFormatV1, models.BlueprintYamlFormatV2)
+	blueprintDoc, err := parseTemplateMetadataV1(&ymlContent, linter.blueprintDir, linter.blueprintContext)
+	if err != nil {
+		linter.addError(lintRuleSchema, "%s", err)
+		return
+	}
+	// v1 blueprints cannot include other blueprints, parameters can only refer to the ones defined before them
+	definedNames := make(map[string]bool)
+	for _, variable := range blueprintDoc.Variables {
+		name := variable.Name.Value
+		linter.lintReferences(fmt.Sprintf("dependsOn of parameter %s", name), variable.DependsOn, definedNames)
+		definedNames[name] = true
+	}
+	linter.lintFiles(blueprintDoc.TemplateConfigs, definedNames)
+}
+
+func (linter *blueprintLinter) lintV2(ymlContent []byte) {
+	decoder := yaml.NewDecoder(bytes.NewReader(ymlContent))
+	decoder.SetStrict(true)
+	yamlDoc := BlueprintYamlV2{}
+	if err := decoder.Decode(&yamlDoc); err != nil {
+		linter.addError(lintRuleSchema, "%s", err)
+		return
+	}
+	if yamlDoc.ApiVersion != models.BlueprintYamlFormatV2 {
+		linter.addError(lintRuleSchema, "api version needs to be %s or %s", models.BlueprintYamlFormatV2, models.BlueprintYamlFormatV1)
+	}
+	if yamlDoc.Kind != models.BlueprintSpecKind {
+		linter.addError(lintRuleSchema, "yaml document kind needs to be %s", models.BlueprintSpecKind)
+	}
+
+	// parameters of blueprints included before are asked first, so they can be referred to from this blueprint
+	definedNames := linter.lintIncludes("includeBefore", yamlDoc.Spec.IncludeBefore, nil)
+	for i, parameter := range yamlDoc.Spec.Parameters {
+		variable, err := parseParameterV2(&parameter)
+		if err != nil {
+			linter.addError(lintRuleParameter, "parameter #%d: %s", i+1, err)
+		}
+		name := variable.Name.Value
+		if name == "" {
+			continue
+		}
+		if definedNames[name] {
+			linter.addError(lintRuleDuplicate, "parameter %s is defined more than once", name)
+		}
+		linter.lintReferences(fmt.Sprintf("promptIf of parameter %s", name), variable.DependsOn, definedNames)
+		if err == nil {
+			linter.lintParameterOptions(variable)
+		}
+		definedNames[name] = true
+	}
+
+	allNames := linter.lintIncludes("includeAfter", yamlDoc.Spec.IncludeAfter, definedNames)
+
+	var templateConfigs []TemplateConfig
+	for i, file := range yamlDoc.Spec.Files {
+		templateConfig, err := parseFileV2(&file)
+		if err != nil {
+			linter.addError(lintRuleFilePath, "file #%d: %s", i+1, err)
+			continue
+		}
+		templateConfigs = append(templateConfigs, templateConfig)
+	}
+	linter.lintFiles(templateConfigs, allNames)
+}
+
+// lintIncludes checks the included blueprints and returns the given names extended with the parameters they define
+func (linter *blueprintLinter) lintIncludes(stage string, includes []IncludedBlueprintV2, definedNames map[string]bool) map[string]bool {
+	names := make(map[string]bool)
+	for name := range definedNames {
+		names[name] = true
+	}
+	for i, include := range includes {
+		processed, err := parseIncludeV2(&include)
+		if err != nil {
+			linter.addError(lintRuleInvalidInclude, "%s #%d: %s", stage, i+1, err)
+			continue
+		}
+		if processed.Blueprint == "" {
+			linter.addError(lintRuleInvalidInclude, "%s #%d: blueprint field is missing", stage, i+1)
+			continue
+		}
+		linter.lintReferences(fmt.Sprintf("includeIf of %s blueprint %s", stage, processed.Blueprint), processed.DependsOn, names)
+
+		if _, exists := linter.blueprints[processed.Blueprint]; !exists {
+			linter.addError(lintRuleMissingInclude, "%s blueprint %s is not found in repository %s", stage, processed.Blueprint, (*linter.blueprintContext.ActiveRepo).GetName())
+			continue
+		}
+		composedBlueprints, includedDoc, err := getBlueprintConfig(linter.blueprintContext, linter.blueprints, processed.Blueprint, nil, "")
+		if err != nil {
+			linter.addError(lintRuleInvalidInclude, "%s blueprint %s: %s", stage, processed.Blueprint, err)
+			continue
+		}
+		for _, override := range processed.ParameterOverrides {
+			if findParameter(includedDoc.Variables, override.Name) == -1 {
+				linter.addError(lintRuleUnknownOverride, "parameterOverrides of %s blueprint %s: parameter %s is not defined in the included blueprint", stage, processed.Blueprint, override.Name.Value)
+			}
+		}
+		for _, override := range processed.FileOverrides {
+			if findTemplateConfig(includedDoc.TemplateConfigs, override.Path) == -1 {
+				linter.addError(lintRuleUnknownOverride, "fileOverrides of %s blueprint %s: file %s is not defined in the included blueprint", stage, processed.Blueprint, override.Path)
+			}
+		}
+		for _, composed := range composedBlueprints {
+			for _, variable := range composed.BlueprintConfig.Variables {
+				names[variable.Name.Value] = true
+			}
+		}
+	}
+	return names
+}
+
+func (linter *blueprintLinter) lintParameterOptions(variable Variable) {
+	if variable.Type.Value != TypeSelect {
+		return
+	}
+	name := variable.Name.Value
+	if len(variable.Options) == 0 {
+		linter.addError(lintRuleParameter, "at least one option field is need to be set for parameter [%s]", name)
+		return
+	}
+	// options and defaults calculated by expressions can only be checked while generating
+	if variable.Default.Value == "" || variable.Default.Tag != "" {
+		return
+	}
+	for _, option := range variable.Options {
+		if option.Tag != "" || option.Value == variable.Default.Value {
+			return
+		}
+	}
+	linter.addError(lintRuleSelectDefault, "default value %s of parameter %s is not one of its options", variable.Default.Value, name)
+}
+
+// lintReferences checks that the parameter names used in a promptIf, writeIf or includeIf field are defined
+func (linter *blueprintLinter) lintReferences(fieldDesc string, dependsOn VarField, definedNames map[string]bool) {
+	if dependsOn.Value == "" {
+		return
+	}
+	var referencedNames []string
+	switch dependsOn.Tag {
+	case "":
+		if dependsOn.Value == "true" || dependsOn.Value == "false" {
+			return
+		}
+		referencedNames = []string{dependsOn.Value}
+	case tagExpressionV2, tagExpressionV1:
+		expression, err := govaluate.NewEvaluableExpressionWithFunctions(dependsOn.Value, getExpressionFunctions(map[string]interface{}{}, nil))
+		if err != nil {
+			linter.addError(lintRuleExpression, "%s: expression [%s] is not valid: %s", fieldDesc, dependsOn.Value, err)
+			return
+		}
+		referencedNames = expression.Vars()
+	default:
+		return
+	}
+	for _, name := range referencedNames {
+		if !definedNames[name] {
+			linter.addError(lintRuleUndefinedRef, "%s refers to parameter %s which is not defined before it", fieldDesc, name)
+		}
+	}
+}
+
+// lintFiles compares the files listed in the definition with the files in the blueprint directory
+func (linter *blueprintLinter) lintFiles(templateConfigs []TemplateConfig, definedNames map[string]bool) {
+	listedFiles := make(map[string]bool)
+	for _, templateConfig := range templateConfigs {
+		if err := validateFiles(&[]TemplateConfig{templateConfig}); err != nil {
+			linter.addError(lintRuleFilePath, "%s: %s", templateConfig.Path, err)
+			continue
+		}
+		filePath := filepath.Clean(templateConfig.Path)
+		listedFiles[filepath.ToSlash(filePath)] = true
+		linter.lintReferences(fmt.Sprintf("writeIf of file %s", templateConfig.Path), templateConfig.DependsOn, definedNames)
+		if info, err := os.Stat(filepath.Join(linter.blueprintDir, filePath)); err != nil || info.IsDir() {
+			linter.addError(lintRuleMissingFile, "file %s is listed in spec.files but does not exist", templateConfig.Path)
+		}
+	}
+
+	filepath.Walk(linter.blueprintDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		relPath, _ := filepath.Rel(linter.blueprintDir, path)
+		relPath = filepath.ToSlash(relPath)
+		if info.IsDir() {
+			if relPath != "." && (linter.isIgnoredPath(info.Name()) || findDefinitionFile(path) != "") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if relPath == linter.definitionFile {
+			return nil
+		}
+		if !listedFiles[relPath] {
+			linter.addIssue(relPath, LintSeverityError, lintRuleUnlistedFile, "file %s is not listed in spec.files", relPath)
+		}
+		if strings.HasSuffix(relPath, templateExtension) {
+			linter.lintTemplate(path, relPath)
+		}
+		return nil
+	})
+}
+
+func (linter *blueprintLinter) isIgnoredPath(name string) bool {
+	for _, ignored := range ignoredPaths {
+		if name == ignored {
+			return true
+		}
+	}
+	return false
+}
+
+func (linter *blueprintLinter) lintTemplate(path string, relPath string) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		linter.addIssue(relPath, LintSeverityError, lintRuleTemplate, "%s", err)
+		return
+	}
+	if _, err := template.New(relPath).Funcs(getFuncMaps()).Parse(string(content)); err != nil {
+		linter.addIssue(relPath, LintSeverityError, lintRuleTemplate, "%s", err)
+	}
+}
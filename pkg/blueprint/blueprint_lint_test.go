@@ -0,0 +1,187 @@
+package blueprint
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lintRules(issues []LintIssue) []string {
+	rules := make([]string, 0)
+	for _, issue := range issues {
+		rules = append(rules, issue.Rule)
+	}
+	return rules
+}
+
+func TestBlueprintContext_LintBlueprints(t *testing.T) {
+	t.Run("should report no issues for a valid blueprint", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"blueprint.yaml": `
+apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  name: Lint
+spec:
+  parameters:
+  - name: UseProxy
+    type: Confirm
+    prompt: Use a proxy?
+  - name: Proxy
+    type: Select
+    prompt: Which proxy?
+    options: [nginx, haproxy]
+    default: nginx
+    promptIf: UseProxy
+  files:
+  - path: proxy.yaml.tmpl
+    writeIf: !expr "UseProxy && Proxy == 'nginx'"`,
+			"proxy.yaml.tmpl":           `proxy: {{.Proxy}}`,
+			"__test__/test-case-1.yaml": `answers-file: answers.yaml`,
+		})
+		defer cleanup()
+
+		issues, err := getLocalTestBlueprintContext(t).LintBlueprints(dir)
+		require.Nil(t, err)
+		assert.Empty(t, issues)
+	})
+
+	t.Run("should report every problem in one run", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"blueprint.yaml": `
+apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  name: Lint
+spec:
+  parameters:
+  - name: Proxy
+    type: Select
+    prompt: Which proxy?
+    options: [nginx, haproxy]
+    default: apache
+    promptIf: UseProxy
+  - name: UseProxy
+    type: Confirm
+  - name: Proxy
+    value: nginx
+  files:
+  - path: proxy.yaml.tmpl
+    writeIf: !expr "Unknown == 'x'"
+  - path: missing.yaml
+  includeAfter:
+  - blueprint: does-not-exist`,
+			"proxy.yaml.tmpl": `proxy: {{.Proxy}`,
+			"extra.yaml":      `unused: true`,
+		})
+		defer cleanup()
+
+		issues, err := getLocalTestBlueprintContext(t).LintBlueprints(dir)
+		require.Nil(t, err)
+		assert.ElementsMatch(t, []string{
+			lintRuleUndefinedRef,   // promptIf UseProxy defined after Proxy
+			lintRuleSelectDefault,  // apache is not an option
+			lintRuleParameter,      // UseProxy has no prompt
+			lintRuleDuplicate,      // Proxy is defined twice
+			lintRuleUndefinedRef,   // writeIf refers to Unknown
+			lintRuleMissingFile,    // missing.yaml
+			lintRuleMissingInclude, // does-not-exist
+			lintRuleUnlistedFile,   // extra.yaml
+			lintRuleTemplate,       // proxy.yaml.tmpl does not parse
+		}, lintRules(issues))
+		for _, issue := range issues {
+			assert.Equal(t, LintSeverityError, issue.Severity)
+			assert.Equal(t, dir, issue.Blueprint)
+		}
+	})
+
+	t.Run("should resolve parameters of included blueprints", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"blueprint.yaml": `
+apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  name: Lint
+spec:
+  parameters:
+  - name: Confirmed
+    type: Input
+    prompt: Test
+    promptIf: TestDepends
+  includeBefore:
+  - blueprint: valid-no-prompt
+    parameterOverrides:
+    - name: NotThere
+      value: hello`,
+		})
+		defer cleanup()
+
+		issues, err := getLocalTestBlueprintContext(t).LintBlueprints(dir)
+		require.Nil(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, lintRuleUnknownOverride, issues[0].Rule)
+	})
+
+	t.Run("should lint all blueprints below a directory", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"one/blueprint.yaml": "apiVersion: xl/v2\nkind: Blueprint\nspec:\n  parameters: []",
+			"two/blueprint.yml":  "apiVersion: xl/v2\nkind: Wrong",
+		})
+		defer cleanup()
+
+		issues, err := getLocalTestBlueprintContext(t).LintBlueprints(dir)
+		require.Nil(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, filepath.Join(dir, "two"), issues[0].Blueprint)
+		assert.Equal(t, "blueprint.yml", issues[0].File)
+		assert.Equal(t, lintRuleSchema, issues[0].Rule)
+	})
+
+	t.Run("should check the references of v1 blueprints", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"blueprint.yaml": `
+apiVersion: xl/v1
+kind: Blueprint
+metadata:
+  projectName: Lint
+parameters:
+- name: Proxy
+  type: Input
+  description: Which proxy?
+  dependsOnTrue: UseProxy
+- name: UseProxy
+  type: Confirm
+  description: Use a proxy?
+files:
+- path: proxy.yaml.tmpl
+  dependsOnTrue: UseProxy
+- path: nginx.yaml
+  dependsOnFalse: !expression "Unknown == 'x'"`,
+			"proxy.yaml.tmpl": `proxy: {{.Proxy}}`,
+			"nginx.yaml":      `nginx: true`,
+		})
+		defer cleanup()
+
+		issues, err := getLocalTestBlueprintContext(t).LintBlueprints(dir)
+		require.Nil(t, err)
+		assert.ElementsMatch(t, []string{
+			lintRuleDeprecatedSchema,
+			lintRuleUndefinedRef, // dependsOnTrue UseProxy defined after Proxy
+			lintRuleUndefinedRef, // dependsOnFalse refers to Unknown
+		}, lintRules(issues))
+
+		issues, err = getLocalTestBlueprintContext(t).LintBlueprints(GetTestTemplateDir("valid-no-prompt-v1"))
+		require.Nil(t, err)
+		assert.NotContains(t, lintRules(issues), lintRuleUndefinedRef)
+	})
+
+	t.Run("should fail when no blueprint is found", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{})
+		defer cleanup()
+
+		_, err := getLocalTestBlueprintContext(t).LintBlueprints(dir)
+		require.NotNil(t, err)
+	})
+}
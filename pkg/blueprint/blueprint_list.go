@@ -0,0 +1,55 @@
+package blueprint
+
+import (
+	"sort"
+	"strings"
+)
+
+// BlueprintSummary holds the metadata of a single blueprint found in a repository
+type BlueprintSummary struct {
+	Path        string   `json:"path"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Author      string   `json:"author"`
+	Version     string   `json:"version"`
+	Tags        []string `json:"tags,omitempty"`
+	Fragment    bool     `json:"fragment"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// ListBlueprints returns the metadata of all blueprints in the active repository whose path starts with the given prefix
+func (blueprintContext *BlueprintContext) ListBlueprints(pathPrefix string) ([]BlueprintSummary, error) {
+	blueprints, err := blueprintContext.initCurrentRepoClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var blueprintPaths []string
+	for blueprintPath := range blueprints {
+		if strings.HasPrefix(blueprintPath, pathPrefix) {
+			blueprintPaths = append(blueprintPaths, blueprintPath)
+		}
+	}
+	sort.Strings(blueprintPaths)
+
+	summaries := make([]BlueprintSummary, 0, len(blueprintPaths))
+	for _, blueprintPath := range blueprintPaths {
+		summary := BlueprintSummary{
+			Path:     blueprintPath,
+			Fragment: strings.HasPrefix(blueprintPath, fragmentsDir),
+		}
+		// a broken definition file should not hide the rest of the repository
+		blueprintDoc, err := blueprintContext.parseDefinitionFile(blueprints[blueprintPath], blueprintPath)
+		if err != nil {
+			summary.Error = err.Error()
+		} else {
+			summary.Name = blueprintDoc.Metadata.Name
+			summary.Description = strings.TrimSpace(blueprintDoc.Metadata.Description)
+			summary.Author = blueprintDoc.Metadata.Author
+			summary.Version = blueprintDoc.Metadata.Version
+			summary.Tags = blueprintDoc.Metadata.Tags
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,52 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprintContext_ListBlueprints(t *testing.T) {
+	t.Run("should list all blueprints with metadata sorted by path", func(t *testing.T) {
+		summaries, err := getLocalTestBlueprintContext(t).ListBlueprints("")
+		require.Nil(t, err)
+		require.True(t, len(summaries) > 1)
+
+		for i := 1; i < len(summaries); i++ {
+			assert.True(t, summaries[i-1].Path < summaries[i].Path)
+		}
+		var found *BlueprintSummary
+		for i, summary := range summaries {
+			if summary.Path == "answer-input" {
+				found = &summaries[i]
+			}
+		}
+		require.NotNil(t, found)
+		assert.Equal(t, BlueprintSummary{
+			Path:        "answer-input",
+			Name:        "Test Project",
+			Description: "Is just a test blueprint project",
+			Author:      "XebiaLabs",
+			Version:     "1.0",
+		}, *found)
+	})
+
+	t.Run("should filter blueprints by path prefix", func(t *testing.T) {
+		summaries, err := getLocalTestBlueprintContext(t).ListBlueprints("valid-no-prompt")
+		require.Nil(t, err)
+		require.Len(t, summaries, 3)
+		assert.Equal(t, "valid-no-prompt", summaries[0].Path)
+		assert.Equal(t, "valid-no-prompt-suppress-xebia-labs-folder", summaries[1].Path)
+		assert.Equal(t, "valid-no-prompt-v1", summaries[2].Path)
+	})
+
+	t.Run("should report invalid blueprints without failing", func(t *testing.T) {
+		summaries, err := getLocalTestBlueprintContext(t).ListBlueprints("invalid")
+		require.Nil(t, err)
+		require.Len(t, summaries, 1)
+		assert.Equal(t, "parameter AppName must have a 'prompt' field", summaries[0].Error)
+		assert.Empty(t, summaries[0].Name)
+	})
+
+}
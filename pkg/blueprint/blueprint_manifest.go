@@ -0,0 +1,161 @@
+package blueprint
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"github.com/xebialabs/yaml"
+)
+
+const (
+	manifestFile    = ".blueprint.yaml"
+	manifestBaseDir = ".blueprint"
+	manifestHeader  = "# Generated by the blueprint CLI, used by 'upgrade' to apply newer versions of the blueprint. Do not edit."
+)
+
+// BlueprintManifest records which blueprint and answers generated a project, and the files as they were generated
+type BlueprintManifest struct {
+	Blueprint  string            `yaml:"blueprint"`
+	Version    string            `yaml:"version,omitempty"`
+	Repository string            `yaml:"repository,omitempty"`
+	Answers    map[string]string `yaml:"answers,omitempty"`
+	Files      []ManifestFile    `yaml:"files,omitempty"`
+}
+
+// ManifestFile is a generated file with the hash of its generated content
+type ManifestFile struct {
+	Path   string `yaml:"path"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// GetManifestPath returns the path of the manifest for the given xebialabs folder, relative to the base directory
+func GetManifestPath(outputDir string) string {
+	return filepath.Join(outputDir, manifestFile)
+}
+
+// newBlueprintManifest creates the manifest of a generated blueprint, secret answers and file contents are not stored
+func newBlueprintManifest(
+	templatePath string,
+	blueprintContext *BlueprintContext,
+	blueprintDoc *BlueprintConfig,
+	preparedData *PreparedData,
+	generatedBlueprint *GeneratedBlueprint,
+) *BlueprintManifest {
+	manifest := &BlueprintManifest{
+		Blueprint:  templatePath,
+		Version:    blueprintDoc.Metadata.Version,
+		Repository: (*blueprintContext.ActiveRepo).GetName(),
+		Answers:    make(map[string]string),
+	}
+	for _, variable := range blueprintDoc.Variables {
+		// file answers are file contents, and are asked again when upgrading
+		if variable.Value.Value != "" || IsSecretType(variable.Type.Value) || variable.Type.Value == TypeFile {
+			continue
+		}
+		if value, ok := preparedData.TemplateData[variable.Name.Value]; ok && value != nil {
+			manifest.Answers[variable.Name.Value] = fmt.Sprint(value)
+		}
+	}
+	for _, file := range generatedBlueprint.Files {
+		if file.Status == FileStatusSkipped || isInOutputDir(generatedBlueprint, file.Path) {
+			continue
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{Path: filepath.ToSlash(file.Path), SHA256: hashContent(file.Content)})
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	return manifest
+}
+
+// findFile returns the manifest entry for a generated file, or nil when the file was not generated
+func (manifest *BlueprintManifest) findFile(fileName string) *ManifestFile {
+	for i, file := range manifest.Files {
+		if file.Path == filepath.ToSlash(fileName) {
+			return &manifest.Files[i]
+		}
+	}
+	return nil
+}
+
+// writeManifest writes the manifest, and a copy of the generated files used as merge base when upgrading
+func writeManifest(generatedBlueprint *GeneratedBlueprint, manifest *BlueprintManifest, files []FileRecord) error {
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	manifestPath := generatedBlueprint.OutputPath(GetManifestPath(generatedBlueprint.OutputDir))
+	util.Verbose("[file] Writing blueprint manifest %s\n", manifestPath)
+	if err := writeGeneratedFile(generatedBlueprint, manifestPath, manifestHeader+"\n"+string(content)); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if manifest.findFile(file.Path) == nil {
+			continue
+		}
+		basePath := generatedBlueprint.OutputPath(filepath.Join(generatedBlueprint.OutputDir, manifestBaseDir, file.Path))
+		if err := writeGeneratedFile(generatedBlueprint, basePath, file.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadBlueprintManifest reads the manifest of a generated project
+func ReadBlueprintManifest(manifestPath string) (*BlueprintManifest, error) {
+	content, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read blueprint manifest: %s", err)
+	}
+	manifest := &BlueprintManifest{}
+	if err := yaml.UnmarshalStrict(content, manifest); err != nil {
+		return nil, fmt.Errorf("invalid blueprint manifest %s: %s", manifestPath, err)
+	}
+	if manifest.Blueprint == "" {
+		return nil, fmt.Errorf("invalid blueprint manifest %s: blueprint is missing", manifestPath)
+	}
+	return manifest, nil
+}
+
+// readManifestBase returns the content of a file as it was generated, or false when it is not available
+func readManifestBase(generatedBlueprint *GeneratedBlueprint, fileName string) (string, bool) {
+	content, err := ioutil.ReadFile(generatedBlueprint.OutputPath(filepath.Join(generatedBlueprint.OutputDir, manifestBaseDir, fileName)))
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
+
+// writeGeneratedFile writes a file that is part of the blueprint output but not reported as an output file
+func writeGeneratedFile(generatedBlueprint *GeneratedBlueprint, filePath string, data string) error {
+	file, err := generatedBlueprint.createStagedFile(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// isInOutputDir checks if an output file is in the xebialabs folder, these files are generated from the answers only
+func isInOutputDir(generatedBlueprint *GeneratedBlueprint, fileName string) bool {
+	outputDir := filepath.Clean(generatedBlueprint.OutputDir) + string(filepath.Separator)
+	return strings.HasPrefix(filepath.Clean(fileName), outputDir)
+}
+
+// isManifestFile checks if a generated file is the manifest or one of the generated file copies kept with it
+func isManifestFile(generatedBlueprint *GeneratedBlueprint, filePath string) bool {
+	filePath = filepath.Clean(filePath)
+	baseDir := generatedBlueprint.OutputPath(filepath.Join(generatedBlueprint.OutputDir, manifestBaseDir))
+	return filePath == generatedBlueprint.OutputPath(GetManifestPath(generatedBlueprint.OutputDir)) ||
+		filePath == baseDir || strings.HasPrefix(filePath, baseDir+string(filepath.Separator))
+}
+
+func hashContent(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
@@ -15,8 +15,10 @@ type Metadata struct {
 	Description             string
 	Author                  string
 	Version                 string
+	Tags                    []string
 	Instructions            string
 	SuppressXebiaLabsFolder bool
+	XebiaLabsFolder         string
 }
 
 type Variable struct {
@@ -9,12 +9,14 @@ type BlueprintYamlV2 struct {
 }
 
 type MetadataV2 struct {
-	Name                    string `yaml:"name"`
-	Description             string `yaml:"description"`
-	Author                  string `yaml:"author"`
-	Version                 string `yaml:"version"`
-	Instructions            string `yaml:"instructions"`
-	SuppressXebiaLabsFolder bool   `yaml:"suppressXebiaLabsFolder"`
+	Name                    string   `yaml:"name"`
+	Description             string   `yaml:"description"`
+	Author                  string   `yaml:"author"`
+	Version                 string   `yaml:"version"`
+	Tags                    []string `yaml:"tags"`
+	Instructions            string   `yaml:"instructions"`
+	SuppressXebiaLabsFolder bool     `yaml:"suppressXebiaLabsFolder"`
+	XebiaLabsFolder         string   `yaml:"xebialabsFolder"`
 }
 
 type SpecV2 struct {
@@ -52,8 +52,10 @@ func (yamlDoc *BlueprintYamlV2) parseToMetadata() Metadata {
 		Description:             yamlDoc.Metadata.Description,
 		Author:                  yamlDoc.Metadata.Author,
 		Version:                 yamlDoc.Metadata.Version,
+		Tags:                    yamlDoc.Metadata.Tags,
 		Instructions:            yamlDoc.Metadata.Instructions,
 		SuppressXebiaLabsFolder: yamlDoc.Metadata.SuppressXebiaLabsFolder,
+		XebiaLabsFolder:         yamlDoc.Metadata.XebiaLabsFolder,
 	}
 }
 
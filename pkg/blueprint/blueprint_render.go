@@ -0,0 +1,189 @@
+package blueprint
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+)
+
+// archive formats of a rendered blueprint
+const (
+	ArchiveFormatZip = "zip"
+	ArchiveFormatTar = "tar"
+)
+
+// ArchiveFormats lists the supported archive formats of a rendered blueprint
+var ArchiveFormats = []string{ArchiveFormatZip, ArchiveFormatTar}
+
+// getRenderParams returns the parameters to generate a blueprint from the given answers only, missing answers are
+// errors instead of questions
+func getRenderParams(templatePath string, answers map[string]string, useDefaults bool) BlueprintParams {
+	if answers == nil {
+		answers = make(map[string]string)
+	}
+	return BlueprintParams{
+		TemplatePath:       templatePath,
+		AnswersMap:         answers,
+		StrictAnswers:      true,
+		UseDefaultsAsValue: useDefaults,
+	}
+}
+
+// withoutPrompts calls fn with survey options reading from the null device, so that a question that would still be
+// asked fails instead of waiting for input on the terminal of the process
+func withoutPrompts(fn func(surveyOpts ...survey.AskOpt) error) error {
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+	err = fn(survey.WithStdio(devNull, devNull, devNull))
+	if err == io.EOF {
+		return fmt.Errorf("an answer is missing and questions cannot be asked, give all the answers of the blueprint")
+	}
+	return err
+}
+
+// withFileAnswers calls fn with the answers of File and SecretFile parameters written to temporary files and replaced
+// by their paths. These answers are the contents of the files, never a path, so that no file of the host can be read
+// through an answer.
+func (blueprintContext *BlueprintContext) withFileAnswers(templatePath string, answers map[string]string, fn func(answers map[string]string) error) error {
+	repoContext, templatePath, err := blueprintContext.ResolveBlueprintPath(templatePath)
+	if err != nil {
+		return err
+	}
+	description, err := repoContext.DescribeBlueprint(templatePath)
+	if err != nil {
+		return err
+	}
+	uploadDir, err := ioutil.TempDir("", "blueprint-answers")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(uploadDir)
+
+	fileAnswers := make(map[string]string)
+	for name, answer := range answers {
+		fileAnswers[name] = answer
+	}
+	for _, parameter := range description.Parameters {
+		answer, ok := answers[parameter.Name]
+		if !ok || (parameter.Type != TypeFile && parameter.Type != TypeSecretFile) {
+			continue
+		}
+		filePath := filepath.Join(uploadDir, parameter.Name)
+		if err := ioutil.WriteFile(filePath, []byte(answer), 0600); err != nil {
+			return err
+		}
+		fileAnswers[parameter.Name] = filePath
+	}
+	return fn(fileAnswers)
+}
+
+// ValidateAnswers checks that the answers are complete and valid for a blueprint of the active repository, a blueprint
+// of another repository can be given as repo-name:blueprint/path. The answers of File parameters are the contents of
+// the files. Nothing is generated.
+func (blueprintContext *BlueprintContext) ValidateAnswers(templatePath string, answers map[string]string, useDefaults bool) error {
+	if templatePath == "" {
+		return fmt.Errorf("blueprint path is required")
+	}
+	repoContext, templatePath, err := blueprintContext.ResolveBlueprintPath(templatePath)
+	if err != nil {
+		return err
+	}
+	blueprints, err := repoContext.initCurrentRepoClient()
+	if err != nil {
+		return err
+	}
+	return repoContext.withFileAnswers(templatePath, answers, func(answers map[string]string) error {
+		return withoutPrompts(func(surveyOpts ...survey.AskOpt) error {
+			params := getRenderParams(templatePath, answers, useDefaults)
+			_, _, _, err := prepareMergedTemplateData(repoContext, blueprints, params, nil, surveyOpts...)
+			return err
+		})
+	})
+}
+
+// RenderBlueprint generates a blueprint from the given answers without asking any question and without writing any
+// file, the generated files are recorded with their content in the returned blueprint. The answers of File parameters
+// are the contents of the files.
+func (blueprintContext *BlueprintContext) RenderBlueprint(templatePath string, answers map[string]string, useDefaults bool) (*GeneratedBlueprint, error) {
+	if templatePath == "" {
+		return nil, fmt.Errorf("blueprint path is required")
+	}
+	// an empty base directory, so that all files are created and none is in conflict
+	baseDir, err := ioutil.TempDir("", "blueprint-render")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(baseDir)
+
+	generatedBlueprint := &GeneratedBlueprint{BaseDir: baseDir, OutputDir: models.BlueprintOutputDir, DryRun: true}
+	err = blueprintContext.withFileAnswers(templatePath, answers, func(answers map[string]string) error {
+		return withoutPrompts(func(surveyOpts ...survey.AskOpt) error {
+			params := getRenderParams(templatePath, answers, useDefaults)
+			_, _, err := InstantiateBlueprint(params, blueprintContext, generatedBlueprint, nil, surveyOpts...)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return generatedBlueprint, nil
+}
+
+// WriteArchive writes the files generated by a blueprint as a zip or tar archive, skipped files are left out
+func (generatedBlueprint *GeneratedBlueprint) WriteArchive(w io.Writer, format string) error {
+	var files []FileRecord
+	for _, file := range generatedBlueprint.Files {
+		if file.Status != FileStatusSkipped && file.Status != FileStatusKept {
+			files = append(files, file)
+		}
+	}
+
+	modified := time.Now()
+	switch format {
+	case ArchiveFormatZip:
+		archive := zip.NewWriter(w)
+		for _, file := range files {
+			header := &zip.FileHeader{Name: filepath.ToSlash(file.Path), Method: zip.Deflate, Modified: modified}
+			header.SetMode(0644)
+			entry, err := archive.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(entry, file.Content); err != nil {
+				return err
+			}
+		}
+		return archive.Close()
+	case ArchiveFormatTar:
+		archive := tar.NewWriter(w)
+		for _, file := range files {
+			header := &tar.Header{
+				Name:     filepath.ToSlash(file.Path),
+				Mode:     0644,
+				Size:     int64(len(file.Content)),
+				ModTime:  modified,
+				Typeflag: tar.TypeReg,
+			}
+			if err := archive.WriteHeader(header); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(archive, file.Content); err != nil {
+				return err
+			}
+		}
+		return archive.Close()
+	default:
+		return fmt.Errorf("archive format '%s' is not supported, supported formats are %v", format, ArchiveFormats)
+	}
+}
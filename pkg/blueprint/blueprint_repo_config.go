@@ -0,0 +1,125 @@
+package blueprint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+// GetRepositoriesFromConfig returns the current repository name and all repository definitions in the config file
+func GetRepositoriesFromConfig(configPath string) (string, []ConfMap, error) {
+	v, err := readRepositoryConfig(configPath)
+	if err != nil {
+		return "", nil, err
+	}
+	return v.GetString(ViperKeyBlueprintCurrentRepository), GetRepositoriesWithDefault(v), nil
+}
+
+// GetRepositoryFromConfig returns the definition of the named repository in the config file
+func GetRepositoryFromConfig(configPath string, name string) (ConfMap, error) {
+	_, repositories, err := GetRepositoriesFromConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	index := findRepositoryDefinition(repositories, name)
+	if index == -1 {
+		return nil, fmt.Errorf("repository '%s' is not defined", name)
+	}
+	return repositories[index], nil
+}
+
+// AddRepositoryToConfig validates the repository definition with its provider and saves it to the config file
+func AddRepositoryToConfig(configPath string, repoDefinition ConfMap, setCurrent bool, CLIVersion string) error {
+	if !util.MapContainsKeyWithVal(repoDefinition, "type") || !util.MapContainsKeyWithVal(repoDefinition, "name") {
+		return fmt.Errorf("repository doesn't have all mandatory fields set [type, name]")
+	}
+	repoProvider, err := models.GetRepoProvider(repoDefinition["type"])
+	if err != nil {
+		return err
+	}
+	repoDefinition["type"] = repoProvider
+	if _, err := NewBlueprintRepository(repoDefinition, CLIVersion); err != nil {
+		return err
+	}
+
+	v, err := readRepositoryConfig(configPath)
+	if err != nil {
+		return err
+	}
+	repositories := GetRepositoriesWithDefault(v)
+	if findRepositoryDefinition(repositories, repoDefinition["name"]) != -1 {
+		return fmt.Errorf("repository '%s' is already defined", repoDefinition["name"])
+	}
+	v.Set(RepositoryConfigKey, append(repositories, repoDefinition))
+	if setCurrent {
+		v.Set(ViperKeyBlueprintCurrentRepository, repoDefinition["name"])
+	}
+	return writeConfigFile(v, configPath)
+}
+
+// RemoveRepositoryFromConfig removes the named repository from the config file, the default repository cannot be removed
+func RemoveRepositoryFromConfig(configPath string, name string) error {
+	if strings.EqualFold(name, models.DefaultBlueprintRepositoryName) {
+		return fmt.Errorf("the default repository '%s' cannot be removed", models.DefaultBlueprintRepositoryName)
+	}
+	v, err := readRepositoryConfig(configPath)
+	if err != nil {
+		return err
+	}
+	repositories := GetRepositoriesWithDefault(v)
+	index := findRepositoryDefinition(repositories, name)
+	if index == -1 {
+		return fmt.Errorf("repository '%s' is not defined", name)
+	}
+	v.Set(RepositoryConfigKey, append(repositories[:index], repositories[index+1:]...))
+
+	// fall back to the default repository when the current one is removed
+	if strings.EqualFold(v.GetString(ViperKeyBlueprintCurrentRepository), name) {
+		util.Info("Current repository is set to '%s'\n", models.DefaultBlueprintRepositoryName)
+		v.Set(ViperKeyBlueprintCurrentRepository, models.DefaultBlueprintRepositoryName)
+	}
+	return writeConfigFile(v, configPath)
+}
+
+// SetCurrentRepositoryInConfig makes the named repository the active one in the config file
+func SetCurrentRepositoryInConfig(configPath string, name string) error {
+	v, err := readRepositoryConfig(configPath)
+	if err != nil {
+		return err
+	}
+	repositories := GetRepositoriesWithDefault(v)
+	index := findRepositoryDefinition(repositories, name)
+	if index == -1 {
+		return fmt.Errorf("repository '%s' is not defined", name)
+	}
+	v.Set(RepositoryConfigKey, repositories)
+	v.Set(ViperKeyBlueprintCurrentRepository, repositories[index]["name"])
+	return writeConfigFile(v, configPath)
+}
+
+func readRepositoryConfig(configPath string) (*viper.Viper, error) {
+	v := viper.New()
+	if util.PathExists(configPath, false) {
+		var err error
+		v, err = readConfigFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if v.GetString(ViperKeyBlueprintCurrentRepository) == "" {
+		v.Set(ViperKeyBlueprintCurrentRepository, models.DefaultBlueprintRepositoryName)
+	}
+	return v, nil
+}
+
+func findRepositoryDefinition(repositories []ConfMap, name string) int {
+	for i, repo := range repositories {
+		if strings.EqualFold(repo["name"], name) {
+			return i
+		}
+	}
+	return -1
+}
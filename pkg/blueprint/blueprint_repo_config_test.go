@@ -0,0 +1,162 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+)
+
+func writeTestRepositoryConfig(t *testing.T, content string) (string, func()) {
+	confPath, err := ioutil.TempDir("", "xebialabsconfig")
+	require.Nil(t, err)
+	configFile := filepath.Join(confPath, "config.yaml")
+	require.Nil(t, ioutil.WriteFile(configFile, []byte(content), 0640))
+	return configFile, func() { os.RemoveAll(confPath) }
+}
+
+const testRepositoryConfig = `
+xl-deploy:
+  url: http://localhost:4516/
+blueprint:
+  current-repository: XL Github
+  repositories:
+  - name: XL Blueprints
+    type: http
+    url: https://dist.xebialabs.com/public/blueprints/${CLIVersion}/
+  - name: XL Github
+    type: github
+    owner: xebialabs
+    repo-name: blueprints
+    branch: master`
+
+func TestGetRepositoriesFromConfig(t *testing.T) {
+	t.Run("should read current repository and definitions", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		current, repositories, err := GetRepositoriesFromConfig(configFile)
+		require.Nil(t, err)
+		assert.Equal(t, "XL Github", current)
+		require.Len(t, repositories, 2)
+		assert.Equal(t, "XL Blueprints", repositories[0]["name"])
+		assert.Equal(t, "xebialabs", repositories[1]["owner"])
+	})
+
+	t.Run("should return the default repository when config file does not exist", func(t *testing.T) {
+		current, repositories, err := GetRepositoriesFromConfig(filepath.Join(os.TempDir(), "not-existing-config.yaml"))
+		require.Nil(t, err)
+		assert.Equal(t, models.DefaultBlueprintRepositoryName, current)
+		assert.Equal(t, []ConfMap{defaultBlueprintRepo}, repositories)
+	})
+}
+
+func TestAddRepositoryToConfig(t *testing.T) {
+	t.Run("should add a valid repository and keep other settings", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		err := AddRepositoryToConfig(configFile, ConfMap{"name": "Local", "type": "Local", "path": os.TempDir()}, true, DummyCLIVersion)
+		require.Nil(t, err)
+
+		current, repositories, err := GetRepositoriesFromConfig(configFile)
+		require.Nil(t, err)
+		assert.Equal(t, "Local", current)
+		require.Len(t, repositories, 3)
+		assert.Equal(t, ConfMap{"name": "Local", "type": models.ProviderLocal, "path": os.TempDir()}, repositories[2])
+
+		v, err := readConfigFile(configFile)
+		require.Nil(t, err)
+		assert.Equal(t, "http://localhost:4516/", v.GetString("xl-deploy.url"))
+	})
+
+	t.Run("should not add a repository missing provider fields", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		err := AddRepositoryToConfig(configFile, ConfMap{"name": "Other Github", "type": "github", "owner": "xebialabs"}, false, DummyCLIVersion)
+		require.NotNil(t, err)
+		assert.Equal(t, "'repo-name' config field must be set for GitHub repository type", err.Error())
+
+		_, repositories, err := GetRepositoriesFromConfig(configFile)
+		require.Nil(t, err)
+		assert.Len(t, repositories, 2)
+	})
+
+	t.Run("should not add a repository with unknown type", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		err := AddRepositoryToConfig(configFile, ConfMap{"name": "Other", "type": "svn"}, false, DummyCLIVersion)
+		require.NotNil(t, err)
+		assert.Equal(t, "svn is not supported as repository provider", err.Error())
+	})
+
+	t.Run("should not add a repository with a name already in use", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		err := AddRepositoryToConfig(configFile, ConfMap{"name": "xl github", "type": "local", "path": os.TempDir()}, false, DummyCLIVersion)
+		require.NotNil(t, err)
+		assert.Equal(t, "repository 'xl github' is already defined", err.Error())
+	})
+}
+
+func TestRemoveRepositoryFromConfig(t *testing.T) {
+	t.Run("should remove the current repository and fall back to the default", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		require.Nil(t, RemoveRepositoryFromConfig(configFile, "XL Github"))
+
+		current, repositories, err := GetRepositoriesFromConfig(configFile)
+		require.Nil(t, err)
+		assert.Equal(t, models.DefaultBlueprintRepositoryName, current)
+		require.Len(t, repositories, 1)
+		assert.Equal(t, models.DefaultBlueprintRepositoryName, repositories[0]["name"])
+	})
+
+	t.Run("should not remove the default repository", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		err := RemoveRepositoryFromConfig(configFile, models.DefaultBlueprintRepositoryName)
+		require.NotNil(t, err)
+		assert.Equal(t, "the default repository 'XL Blueprints' cannot be removed", err.Error())
+	})
+
+	t.Run("should error on unknown repository", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		err := RemoveRepositoryFromConfig(configFile, "unknown")
+		require.NotNil(t, err)
+		assert.Equal(t, "repository 'unknown' is not defined", err.Error())
+	})
+}
+
+func TestSetCurrentRepositoryInConfig(t *testing.T) {
+	t.Run("should set the current repository using the defined name", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		require.Nil(t, SetCurrentRepositoryInConfig(configFile, "xl blueprints"))
+
+		current, _, err := GetRepositoriesFromConfig(configFile)
+		require.Nil(t, err)
+		assert.Equal(t, models.DefaultBlueprintRepositoryName, current)
+	})
+
+	t.Run("should error on unknown repository", func(t *testing.T) {
+		configFile, cleanup := writeTestRepositoryConfig(t, testRepositoryConfig)
+		defer cleanup()
+
+		err := SetCurrentRepositoryInConfig(configFile, "unknown")
+		require.NotNil(t, err)
+		assert.Equal(t, "repository 'unknown' is not defined", err.Error())
+	})
+}
@@ -0,0 +1,78 @@
+package blueprint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BlueprintInclusion records whether a blueprint of the composition was included in a generation run
+type BlueprintInclusion struct {
+	Blueprint  string   `json:"blueprint"`
+	IncludedBy string   `json:"includedBy,omitempty"`
+	IncludeIf  []string `json:"includeIf,omitempty"`
+	Included   bool     `json:"included"`
+
+	templateConfigs []TemplateConfig
+}
+
+func newBlueprintInclusion(blueprintDoc *ComposedBlueprint, included bool) BlueprintInclusion {
+	return BlueprintInclusion{
+		Blueprint:       blueprintDoc.Name,
+		IncludedBy:      blueprintDoc.Parent,
+		IncludeIf:       getIncludeConditions(blueprintDoc),
+		Included:        included,
+		templateConfigs: blueprintDoc.BlueprintConfig.TemplateConfigs,
+	}
+}
+
+// skipReason explains why the files of a blueprint that is not included are not written
+func (inclusion BlueprintInclusion) skipReason() string {
+	if len(inclusion.IncludeIf) == 0 {
+		return fmt.Sprintf("blueprint %s is not included because %s is not included", inclusion.Blueprint, inclusion.IncludedBy)
+	}
+	return fmt.Sprintf("blueprint %s is not included, includeIf condition [%s] is not met", inclusion.Blueprint, strings.Join(inclusion.IncludeIf, ", "))
+}
+
+// BlueprintResult is the machine readable result of a generation run, secret answers and file contents are not part of it
+type BlueprintResult struct {
+	Blueprint    string                 `json:"blueprint"`
+	Repository   string                 `json:"repository,omitempty"`
+	Version      string                 `json:"version,omitempty"`
+	DryRun       bool                   `json:"dryRun"`
+	Composition  []BlueprintInclusion   `json:"composition"`
+	Files        []FileRecord           `json:"files"`
+	Values       map[string]interface{} `json:"values"`
+	Instructions string                 `json:"instructions,omitempty"`
+}
+
+// NewBlueprintResult creates the result of a generation run from the generated blueprint and the merged blueprint
+func NewBlueprintResult(generatedBlueprint *GeneratedBlueprint, blueprintDoc *BlueprintConfig, preparedData *PreparedData) *BlueprintResult {
+	result := &BlueprintResult{
+		DryRun:       generatedBlueprint.DryRun,
+		Composition:  generatedBlueprint.Composition,
+		Files:        generatedBlueprint.Files,
+		Values:       make(map[string]interface{}),
+		Instructions: strings.TrimSpace(blueprintDoc.Metadata.Instructions),
+	}
+	if generatedBlueprint.Manifest != nil {
+		result.Blueprint = generatedBlueprint.Manifest.Blueprint
+		result.Repository = generatedBlueprint.Manifest.Repository
+		result.Version = generatedBlueprint.Manifest.Version
+	}
+	if result.Composition == nil {
+		result.Composition = []BlueprintInclusion{}
+	}
+	if result.Files == nil {
+		result.Files = []FileRecord{}
+	}
+	for _, variable := range blueprintDoc.Variables {
+		// file answers are file contents, which are not part of the result
+		if IsSecretType(variable.Type.Value) || variable.Type.Value == TypeFile {
+			continue
+		}
+		if value, ok := preparedData.TemplateData[variable.Name.Value]; ok && value != nil {
+			result.Values[variable.Name.Value] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package blueprint
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBlueprintResult(t *testing.T) {
+	SkipFinalPrompt = true
+	dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"repo/app/blueprint.yaml": `apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  name: App
+  version: 1.2
+  instructions: Run xl apply
+spec:
+  parameters:
+  - name: AppName
+    type: Input
+    prompt: Name?
+  - name: Password
+    type: SecretInput
+    prompt: Password?
+  - name: UseDb
+    type: Confirm
+    prompt: Database?
+  - name: Static
+    value: static
+  files:
+  - path: app.yaml
+  - path: debug.yaml
+    writeIf: !expr AppName == 'debug'
+  includeAfter:
+  - blueprint: db
+    includeIf: !expr UseDb
+`,
+		"repo/app/app.yaml":   "app",
+		"repo/app/debug.yaml": "debug",
+		"repo/db/blueprint.yaml": `apiVersion: xl/v2
+kind: Blueprint
+spec:
+  parameters:
+  - name: Size
+    type: Input
+    prompt: Size?
+  files:
+  - path: db.yaml
+`,
+		"repo/db/db.yaml": "db",
+	})
+	defer cleanup()
+	blueprintContext, err := ConstructLocalBlueprintContext(filepath.Join(dir, "repo"))
+	require.Nil(t, err)
+
+	gb := &GeneratedBlueprint{BaseDir: filepath.Join(dir, "project"), OutputDir: "xebialabs", DryRun: true}
+	preparedData, blueprintDoc, err := InstantiateBlueprint(
+		BlueprintParams{
+			TemplatePath:  "app",
+			StrictAnswers: true,
+			AnswersMap:    map[string]string{"AppName": "demo", "Password": "s3cret", "UseDb": "false"},
+		},
+		blueprintContext, gb, nil,
+	)
+	require.Nil(t, err)
+
+	result := NewBlueprintResult(gb, blueprintDoc, preparedData)
+	assert.Equal(t, "app", result.Blueprint)
+	assert.Equal(t, "1.2", result.Version)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, "Run xl apply", result.Instructions)
+	assert.Equal(t, map[string]interface{}{"AppName": "demo", "UseDb": false, "Static": "static"}, result.Values)
+
+	require.Len(t, result.Composition, 2)
+	assert.Equal(t, "app", result.Composition[0].Blueprint)
+	assert.True(t, result.Composition[0].Included)
+	assert.Equal(t, "db", result.Composition[1].Blueprint)
+	assert.Equal(t, "app", result.Composition[1].IncludedBy)
+	assert.Equal(t, []string{"!expr UseDb"}, result.Composition[1].IncludeIf)
+	assert.False(t, result.Composition[1].Included)
+
+	files := make(map[string]FileRecord)
+	for _, file := range result.Files {
+		files[file.Path] = file
+	}
+	assert.Equal(t, FileStatusCreated, files["app.yaml"].Status)
+	assert.Equal(t, FileRecord{Path: "debug.yaml", Status: FileStatusSkipped, Reason: "writeIf condition [!expr AppName == 'debug'] is not met"}, files["debug.yaml"])
+	assert.Equal(t, FileRecord{Path: "db.yaml", Status: FileStatusSkipped, Reason: "blueprint db is not included, includeIf condition [!expr UseDb] is not met"}, files["db.yaml"])
+}
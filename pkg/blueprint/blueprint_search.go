@@ -0,0 +1,87 @@
+package blueprint
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+)
+
+// repositoryPathSeparator separates the repository name from the blueprint path in a `repo-name:blueprint/path` reference
+const repositoryPathSeparator = ":"
+
+// BlueprintSearchResult is a blueprint matching a search, together with the repository it was found in
+type BlueprintSearchResult struct {
+	Repository string `json:"repository"`
+	BlueprintSummary
+}
+
+// SearchBlueprints queries all defined repositories concurrently for blueprints whose path, name, description or tags
+// contain the term, ignoring case. Fragments are not searched since they cannot be generated on their own.
+// A repository that cannot be read does not fail the search, its error is returned next to the results of the others.
+func (blueprintContext *BlueprintContext) SearchBlueprints(term string) ([]BlueprintSearchResult, []error) {
+	term = strings.ToLower(strings.TrimSpace(term))
+	repoResults := make([][]BlueprintSearchResult, len(blueprintContext.DefinedRepos))
+	repoErrors := make([]error, len(blueprintContext.DefinedRepos))
+
+	var wg sync.WaitGroup
+	for i, repo := range blueprintContext.DefinedRepos {
+		wg.Add(1)
+		go func(i int, repo *repository.BlueprintRepository) {
+			defer wg.Done()
+			repoContext := &BlueprintContext{ActiveRepo: repo, DefinedRepos: blueprintContext.DefinedRepos}
+			summaries, err := repoContext.ListBlueprints("")
+			if err != nil {
+				repoErrors[i] = fmt.Errorf("repository %s: %s", (*repo).GetName(), err)
+				return
+			}
+			for _, summary := range summaries {
+				if !summary.Fragment && summary.matches(term) {
+					repoResults[i] = append(repoResults[i], BlueprintSearchResult{Repository: (*repo).GetName(), BlueprintSummary: summary})
+				}
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+
+	// keep the order of the repository definitions, the blueprints of a repository are already sorted by path
+	results := make([]BlueprintSearchResult, 0)
+	var errs []error
+	for i := range blueprintContext.DefinedRepos {
+		results = append(results, repoResults[i]...)
+		if repoErrors[i] != nil {
+			errs = append(errs, repoErrors[i])
+		}
+	}
+	return results, errs
+}
+
+func (summary BlueprintSummary) matches(term string) bool {
+	fields := append([]string{summary.Path, summary.Name, summary.Description}, summary.Tags...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveBlueprintPath handles a `repo-name:blueprint/path` reference, it returns a copy of the context with the named
+// repository as the active one and the blueprint path within that repository. The context itself is left unchanged,
+// paths without a repository name are returned as they are with the context.
+func (blueprintContext *BlueprintContext) ResolveBlueprintPath(templatePath string) (*BlueprintContext, string, error) {
+	parts := strings.SplitN(templatePath, repositoryPathSeparator, 2)
+	if len(parts) != 2 {
+		return blueprintContext, templatePath, nil
+	}
+	repoName, blueprintPath := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if repoName == "" || blueprintPath == "" {
+		return nil, "", fmt.Errorf("blueprint reference '%s' is not valid, expected format is repo-name:blueprint/path", templatePath)
+	}
+	repo, err := blueprintContext.findRepository(repoName)
+	if err != nil {
+		return nil, "", err
+	}
+	return &BlueprintContext{ActiveRepo: repo, DefinedRepos: blueprintContext.DefinedRepos}, blueprintPath, nil
+}
@@ -0,0 +1,130 @@
+package blueprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/local"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+)
+
+func getSearchTestBlueprintContext(t *testing.T) *BlueprintContext {
+	blueprintContext := &BlueprintContext{}
+	for _, name := range []string{"First", "Second"} {
+		var repo repository.BlueprintRepository
+		repo, err := local.NewLocalBlueprintRepository(map[string]string{
+			"type": models.ProviderLocal,
+			"name": name,
+			"path": filepath.Join(GetTestTemplateDir("search-repos"), strings.ToLower(name)),
+		})
+		require.Nil(t, err)
+		blueprintContext.DefinedRepos = append(blueprintContext.DefinedRepos, &repo)
+	}
+	blueprintContext.ActiveRepo = blueprintContext.DefinedRepos[0]
+	return blueprintContext
+}
+
+func searchResultPaths(results []BlueprintSearchResult) []string {
+	paths := make([]string, 0)
+	for _, result := range results {
+		paths = append(paths, result.Repository+":"+result.Path)
+	}
+	return paths
+}
+
+func TestBlueprintContext_SearchBlueprints(t *testing.T) {
+	blueprintContext := getSearchTestBlueprintContext(t)
+
+	tests := []struct {
+		name string
+		term string
+		want []string
+	}{
+		{"matches path", "aws/", []string{"First:aws/datalake", "First:aws/microservice"}},
+		{"matches name ignoring case", "data LAKE", []string{"First:aws/datalake"}},
+		{"matches description and tags in all repositories", "kubernetes", []string{"First:aws/microservice", "Second:gcp/gke"}},
+		{"no match", "azure", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, errs := blueprintContext.SearchBlueprints(tt.term)
+			assert.Empty(t, errs)
+			assert.Equal(t, tt.want, searchResultPaths(results))
+		})
+	}
+
+	t.Run("should return the metadata of the blueprints found", func(t *testing.T) {
+		results, _ := blueprintContext.SearchBlueprints("gke")
+		require.Len(t, results, 1)
+		assert.Equal(t, "GKE", results[0].Name)
+		assert.Equal(t, []string{"gcp"}, results[0].Tags)
+	})
+
+	t.Run("should report the repositories that cannot be read", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "blueprintsearch")
+		require.Nil(t, err)
+		var repo repository.BlueprintRepository
+		repo, err = local.NewLocalBlueprintRepository(map[string]string{"type": models.ProviderLocal, "name": "Third", "path": dir})
+		require.Nil(t, err)
+		require.Nil(t, os.RemoveAll(dir))
+		blueprintContext := getSearchTestBlueprintContext(t)
+		blueprintContext.DefinedRepos = append(blueprintContext.DefinedRepos, &repo)
+
+		results, errs := blueprintContext.SearchBlueprints("kubernetes")
+		assert.Equal(t, []string{"First:aws/microservice", "Second:gcp/gke"}, searchResultPaths(results))
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), "repository Third: ")
+	})
+}
+
+func TestBlueprintContext_ResolveBlueprintPath(t *testing.T) {
+	t.Run("should keep the active repository for a plain path", func(t *testing.T) {
+		blueprintContext := getSearchTestBlueprintContext(t)
+		repoContext, blueprintPath, err := blueprintContext.ResolveBlueprintPath("aws/datalake")
+		require.Nil(t, err)
+		assert.Equal(t, "aws/datalake", blueprintPath)
+		assert.Equal(t, "First", (*repoContext.ActiveRepo).GetName())
+	})
+
+	t.Run("should use the named repository", func(t *testing.T) {
+		blueprintContext := getSearchTestBlueprintContext(t)
+		repoContext, blueprintPath, err := blueprintContext.ResolveBlueprintPath("second:gcp/gke")
+		require.Nil(t, err)
+		assert.Equal(t, "gcp/gke", blueprintPath)
+		assert.Equal(t, "Second", (*repoContext.ActiveRepo).GetName())
+		assert.Equal(t, "First", (*blueprintContext.ActiveRepo).GetName())
+	})
+
+	t.Run("should fail for an unknown repository or an incomplete reference", func(t *testing.T) {
+		blueprintContext := getSearchTestBlueprintContext(t)
+		_, _, err := blueprintContext.ResolveBlueprintPath("Third:gcp/gke")
+		require.NotNil(t, err)
+		assert.Equal(t, "repository 'Third' is not defined", err.Error())
+		_, _, err = blueprintContext.ResolveBlueprintPath("Second:")
+		require.NotNil(t, err)
+		assert.Equal(t, "blueprint reference 'Second:' is not valid, expected format is repo-name:blueprint/path", err.Error())
+	})
+
+	t.Run("should generate a blueprint of another repository", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "blueprintsearch")
+		require.Nil(t, err)
+		defer os.RemoveAll(dir)
+		blueprintContext := getSearchTestBlueprintContext(t)
+		gb := &GeneratedBlueprint{BaseDir: filepath.Join(dir, "generated"), OutputDir: "xebialabs"}
+		_, _, err = InstantiateBlueprint(
+			BlueprintParams{TemplatePath: "Second:gcp/gke", AnswersMap: map[string]string{}, StrictAnswers: true},
+			blueprintContext, gb, nil,
+		)
+		require.Nil(t, err)
+		assert.Equal(t, "app", GetFileContent(filepath.Join(dir, "generated", "app.yaml")))
+		assert.Equal(t, "Second", gb.Manifest.Repository)
+		assert.Equal(t, "gcp/gke", gb.Manifest.Blueprint)
+		assert.Equal(t, "First", (*blueprintContext.ActiveRepo).GetName())
+	})
+}
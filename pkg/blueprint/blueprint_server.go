@@ -0,0 +1,229 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+)
+
+// BlueprintServer exposes the repositories of a blueprint context over a REST API.
+// Repositories and the blueprint engine keep state while they are used, so requests are handled one at a time.
+type BlueprintServer struct {
+	sync.Mutex
+	blueprintContext *BlueprintContext
+	mux              *http.ServeMux
+}
+
+// RepositorySummary is a defined repository as returned by the server
+type RepositorySummary struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	Active   bool   `json:"active"`
+}
+
+// AnswersRequest is the body of the validate and render requests. The blueprint is a path in the active repository,
+// or repo-name:blueprint/path, and answers can be strings, numbers or booleans.
+type AnswersRequest struct {
+	Blueprint   string                 `json:"blueprint"`
+	Answers     map[string]interface{} `json:"answers"`
+	UseDefaults bool                   `json:"useDefaults"`
+	Format      string                 `json:"format"`
+}
+
+// ValidationResult is the response of the validate request
+type ValidationResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// maxRequestSize limits the size of the body of the validate and render requests
+const maxRequestSize = 10 << 20
+
+type errorResponse struct {
+	Error          string         `json:"error"`
+	TemplateErrors TemplateErrors `json:"templateErrors,omitempty"`
+}
+
+// NewBlueprintServer creates the HTTP handler of the blueprint REST API
+func NewBlueprintServer(blueprintContext *BlueprintContext) *BlueprintServer {
+	server := &BlueprintServer{blueprintContext: blueprintContext, mux: http.NewServeMux()}
+	server.mux.HandleFunc("/api/v1/repositories", server.handle(http.MethodGet, server.listRepositories))
+	server.mux.HandleFunc("/api/v1/blueprints", server.handle(http.MethodGet, server.listBlueprints))
+	server.mux.HandleFunc("/api/v1/blueprint", server.handle(http.MethodGet, server.describeBlueprint))
+	server.mux.HandleFunc("/api/v1/validate", server.handle(http.MethodPost, server.validateAnswers))
+	server.mux.HandleFunc("/api/v1/render", server.handle(http.MethodPost, server.renderBlueprint))
+	return server
+}
+
+func (server *BlueprintServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mux.ServeHTTP(w, r)
+}
+
+// handle checks the method of the request and runs the handler with a blueprint context of its own, so that a
+// repo-name:blueprint/path reference does not change the active repository of the next requests
+func (server *BlueprintServer) handle(method string, handler func(http.ResponseWriter, *http.Request, *BlueprintContext)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		util.Verbose("[server] %s %s\n", r.Method, r.URL)
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		server.Lock()
+		defer server.Unlock()
+		handler(w, r, &BlueprintContext{
+			ActiveRepo:   server.blueprintContext.ActiveRepo,
+			DefinedRepos: server.blueprintContext.DefinedRepos,
+		})
+	}
+}
+
+// listRepositories handles GET /api/v1/repositories
+func (server *BlueprintServer) listRepositories(w http.ResponseWriter, r *http.Request, blueprintContext *BlueprintContext) {
+	repositories := make([]RepositorySummary, 0, len(blueprintContext.DefinedRepos))
+	for _, repo := range blueprintContext.DefinedRepos {
+		repositories = append(repositories, RepositorySummary{
+			Name:     (*repo).GetName(),
+			Provider: (*repo).GetProvider(),
+			Active:   repo == blueprintContext.ActiveRepo,
+		})
+	}
+	writeJSON(w, http.StatusOK, repositories)
+}
+
+// listBlueprints handles GET /api/v1/blueprints?repository=NAME, the active repository is used when none is given
+func (server *BlueprintServer) listBlueprints(w http.ResponseWriter, r *http.Request, blueprintContext *BlueprintContext) {
+	if name := r.URL.Query().Get("repository"); name != "" {
+		if err := blueprintContext.UseRepository(name); err != nil {
+			writeJSONError(w, http.StatusNotFound, err)
+			return
+		}
+	}
+	summaries, err := blueprintContext.ListBlueprints("")
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// describeBlueprint handles GET /api/v1/blueprint?blueprint=PATH and returns the parameter schema of the blueprint
+func (server *BlueprintServer) describeBlueprint(w http.ResponseWriter, r *http.Request, blueprintContext *BlueprintContext) {
+	templatePath := r.URL.Query().Get("blueprint")
+	if templatePath == "" {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("query parameter 'blueprint' is required"))
+		return
+	}
+	repoContext, templatePath, err := blueprintContext.ResolveBlueprintPath(templatePath)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+	description, err := repoContext.DescribeBlueprint(templatePath)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, description)
+}
+
+// validateAnswers handles POST /api/v1/validate, invalid answers are reported in the result
+func (server *BlueprintServer) validateAnswers(w http.ResponseWriter, r *http.Request, blueprintContext *BlueprintContext) {
+	request, err := readAnswersRequest(w, r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	result := ValidationResult{Valid: true}
+	if err := blueprintContext.ValidateAnswers(request.Blueprint, request.answers(), request.UseDefaults); err != nil {
+		result = ValidationResult{Error: err.Error()}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// renderBlueprint handles POST /api/v1/render and returns the generated files as a zip or tar archive
+func (server *BlueprintServer) renderBlueprint(w http.ResponseWriter, r *http.Request, blueprintContext *BlueprintContext) {
+	request, err := readAnswersRequest(w, r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if request.Format == "" {
+		request.Format = ArchiveFormatZip
+	}
+	if !util.IsStringInSlice(request.Format, ArchiveFormats) {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("archive format '%s' is not supported, supported formats are %v", request.Format, ArchiveFormats))
+		return
+	}
+	generatedBlueprint, err := blueprintContext.RenderBlueprint(request.Blueprint, request.answers(), request.UseDefaults)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// the archive is built before answering, so that an error can still be reported with its status
+	var archive bytes.Buffer
+	if err := generatedBlueprint.WriteArchive(&archive, request.Format); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	contentType := "application/zip"
+	if request.Format == ArchiveFormatTar {
+		contentType = "application/x-tar"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", path.Base(generatedBlueprint.Manifest.Blueprint), request.Format))
+	w.WriteHeader(http.StatusOK)
+	archive.WriteTo(w)
+}
+
+func readAnswersRequest(w http.ResponseWriter, r *http.Request) (*AnswersRequest, error) {
+	request := &AnswersRequest{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	// keep numbers as they are written, decoding them as float64 would format large ones in exponent notation
+	decoder.UseNumber()
+	if err := decoder.Decode(request); err != nil {
+		return nil, fmt.Errorf("request body is not valid: %s", err)
+	}
+	if request.Blueprint == "" {
+		return nil, fmt.Errorf("field 'blueprint' is required")
+	}
+	return request, nil
+}
+
+// answers returns the answers as strings, as they would be read from an answers file
+func (request *AnswersRequest) answers() map[string]string {
+	answers := make(map[string]string)
+	for name, value := range request.Answers {
+		switch value := value.(type) {
+		case nil:
+		case json.Number:
+			answers[name] = value.String()
+		default:
+			answers[name] = fmt.Sprint(value)
+		}
+	}
+	return answers
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		util.Error("Error while writing response: %s\n", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	response := errorResponse{Error: err.Error()}
+	// template errors are also given one by one, so that clients can point at the broken templates
+	if templateErrors, ok := err.(TemplateErrors); ok {
+		response.TemplateErrors = templateErrors
+	}
+	writeJSON(w, status, response)
+}
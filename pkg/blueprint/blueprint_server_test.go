@@ -0,0 +1,230 @@
+package blueprint
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getServerTestBlueprintServer(t *testing.T) *httptest.Server {
+	blueprintContext, err := ConstructLocalBlueprintContext(GetTestTemplateDir("server-repo"))
+	require.Nil(t, err)
+	return httptest.NewServer(NewBlueprintServer(blueprintContext))
+}
+
+func postJSON(t *testing.T, url string, body string) *http.Response {
+	response, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.Nil(t, err)
+	return response
+}
+
+func decodeJSON(t *testing.T, response *http.Response, v interface{}) {
+	defer response.Body.Close()
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	require.Nil(t, json.NewDecoder(response.Body).Decode(v))
+}
+
+func TestBlueprintServer(t *testing.T) {
+	server := getServerTestBlueprintServer(t)
+	defer server.Close()
+
+	t.Run("should list repositories and blueprints", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/api/v1/repositories")
+		require.Nil(t, err)
+		var repositories []RepositorySummary
+		decodeJSON(t, response, &repositories)
+		assert.Equal(t, []RepositorySummary{{Name: "cmd-arg", Provider: "local", Active: true}}, repositories)
+
+		response, err = http.Get(server.URL + "/api/v1/blueprints")
+		require.Nil(t, err)
+		var summaries []BlueprintSummary
+		decodeJSON(t, response, &summaries)
+		require.Len(t, summaries, 1)
+		assert.Equal(t, "app", summaries[0].Path)
+
+		response, err = http.Get(server.URL + "/api/v1/blueprints?repository=unknown")
+		require.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("should return the parameters of a blueprint", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/api/v1/blueprint?blueprint=app")
+		require.Nil(t, err)
+		var description BlueprintDescription
+		decodeJSON(t, response, &description)
+		require.Len(t, description.Parameters, 3)
+		assert.Equal(t, "AppName", description.Parameters[0].Name)
+		assert.Equal(t, "Name?", description.Parameters[0].Prompt)
+
+		response, err = http.Get(server.URL + "/api/v1/blueprint")
+		require.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("should validate answers", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+			want ValidationResult
+		}{
+			{"valid", `{"blueprint": "app", "answers": {"AppName": "demo", "Replicas": 2, "Public": true}}`, ValidationResult{Valid: true}},
+			{"defaults", `{"blueprint": "app", "answers": {"AppName": "demo", "Public": false}, "useDefaults": true}`, ValidationResult{Valid: true}},
+			{"missing", `{"blueprint": "app", "answers": {"AppName": "demo"}}`, ValidationResult{Error: "variable with name [Replicas] could not be found in answers file"}},
+			{"not valid", `{"blueprint": "app", "answers": {"AppName": "Demo!", "Replicas": 2, "Public": true}}`, ValidationResult{Error: "validation error for answer value [Demo!] for variable [AppName]: validation [regex('^[a-z]+$', AppName)] failed with value [Demo!]"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var result ValidationResult
+				decodeJSON(t, postJSON(t, server.URL+"/api/v1/validate", tt.body), &result)
+				assert.Equal(t, tt.want, result)
+			})
+		}
+	})
+
+	t.Run("should render a blueprint to a zip archive", func(t *testing.T) {
+		response := postJSON(t, server.URL+"/api/v1/render", `{"blueprint": "app", "answers": {"AppName": "demo", "Replicas": 1000000, "Public": true}}`)
+		defer response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "application/zip", response.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="app.zip"`, response.Header.Get("Content-Disposition"))
+
+		content, err := ioutil.ReadAll(response.Body)
+		require.Nil(t, err)
+		archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		require.Nil(t, err)
+		files := make(map[string]string)
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			require.Nil(t, err)
+			data, err := ioutil.ReadAll(reader)
+			require.Nil(t, err)
+			files[file.Name] = string(data)
+		}
+		assert.Equal(t, "name: demo\nreplicas: 1000000\npublic: true", files["app.yaml"])
+		assert.Contains(t, files, "xebialabs/values.xlvals")
+	})
+
+	t.Run("should render a blueprint to a tar archive", func(t *testing.T) {
+		response := postJSON(t, server.URL+"/api/v1/render", `{"blueprint": "app", "format": "tar", "answers": {"AppName": "demo", "Public": false}, "useDefaults": true}`)
+		defer response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "application/x-tar", response.Header.Get("Content-Type"))
+
+		archive := tar.NewReader(response.Body)
+		files := make(map[string]string)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			require.Nil(t, err)
+			data, err := ioutil.ReadAll(archive)
+			require.Nil(t, err)
+			files[header.Name] = string(data)
+		}
+		assert.Equal(t, "name: demo\nreplicas: 1\npublic: false", files["app.yaml"])
+	})
+
+	t.Run("should report errors of a render request", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			status int
+			error  string
+		}{
+			{"not json", `answers`, http.StatusBadRequest, "request body is not valid: invalid character 'a' looking for beginning of value"},
+			{"no blueprint", `{"answers": {}}`, http.StatusBadRequest, "field 'blueprint' is required"},
+			{"unknown format", `{"blueprint": "app", "format": "rar"}`, http.StatusBadRequest, "archive format 'rar' is not supported, supported formats are [zip tar]"},
+			{"missing answers", `{"blueprint": "app"}`, http.StatusUnprocessableEntity, "variable with name [AppName] could not be found in answers file"},
+			{"unknown blueprint", `{"blueprint": "other"}`, http.StatusUnprocessableEntity, "blueprint [other] not found in repository cmd-arg"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				response := postJSON(t, server.URL+"/api/v1/render", tt.body)
+				assert.Equal(t, tt.status, response.StatusCode)
+				var result errorResponse
+				decodeJSON(t, response, &result)
+				assert.Equal(t, tt.error, result.Error)
+			})
+		}
+	})
+
+	t.Run("should only allow the method of the endpoint", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/api/v1/render")
+		require.Nil(t, err)
+		assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+		assert.Equal(t, http.MethodPost, response.Header.Get("Allow"))
+	})
+}
+
+func TestBlueprintServerFileAnswers(t *testing.T) {
+	repoDir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"cert/blueprint.yaml": `apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  name: Cert
+spec:
+  parameters:
+  - name: Certificate
+    type: File
+    prompt: Certificate?
+  files:
+  - path: cert.pem.tmpl
+`,
+		"cert/cert.pem.tmpl": "{{.Certificate}}",
+	})
+	defer cleanup()
+	hostFile, err := ioutil.TempFile("", "blueprint-host")
+	require.Nil(t, err)
+	defer os.Remove(hostFile.Name())
+	_, err = hostFile.WriteString("host secret")
+	require.Nil(t, err)
+	require.Nil(t, hostFile.Close())
+
+	blueprintContext, err := ConstructLocalBlueprintContext(repoDir)
+	require.Nil(t, err)
+	server := httptest.NewServer(NewBlueprintServer(blueprintContext))
+	defer server.Close()
+
+	t.Run("should use the answer of a File parameter as the file content", func(t *testing.T) {
+		body, err := json.Marshal(AnswersRequest{Blueprint: "cert", Format: ArchiveFormatTar, Answers: map[string]interface{}{"Certificate": hostFile.Name()}})
+		require.Nil(t, err)
+		response := postJSON(t, server.URL+"/api/v1/render", string(body))
+		defer response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		archive := tar.NewReader(response.Body)
+		files := make(map[string]string)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			require.Nil(t, err)
+			data, err := ioutil.ReadAll(archive)
+			require.Nil(t, err)
+			files[header.Name] = string(data)
+		}
+		assert.Equal(t, hostFile.Name(), files["cert.pem"])
+	})
+
+	t.Run("should reject a request body that is too large", func(t *testing.T) {
+		body := `{"blueprint": "cert", "answers": {"Certificate": "` + strings.Repeat("a", maxRequestSize) + `"}}`
+		response := postJSON(t, server.URL+"/api/v1/validate", body)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		var result errorResponse
+		decodeJSON(t, response, &result)
+		assert.Equal(t, "request body is not valid: http: request body too large", result.Error)
+	})
+}
@@ -0,0 +1,135 @@
+package blueprint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	templatePhaseParse   = "parse"
+	templatePhaseExecute = "execute"
+
+	// number of lines shown before and after the offending line of a template
+	templateSnippetContext = 2
+)
+
+// templateErrorLocation matches the line, the optional column and the message of a template error, after the name
+var templateErrorLocation = regexp.MustCompile(`(?s)^(\d+)(?::(\d+))?: (.*)$`)
+
+// TemplateError is an error parsing or executing a template file of a blueprint
+type TemplateError struct {
+	Blueprint string `json:"blueprint"`
+	Path      string `json:"path"`
+	Phase     string `json:"phase"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	Message   string `json:"message"`
+	Snippet   string `json:"snippet,omitempty"`
+}
+
+// TemplateErrors are the template errors found in all the files of a blueprint
+type TemplateErrors []*TemplateError
+
+// newTemplateError locates an error of the text/template package in the template content. Parse errors only have a
+// line, execution errors have a line and a column.
+func newTemplateError(blueprintName string, config TemplateConfig, content string, phase string, err error) *TemplateError {
+	templateError := &TemplateError{Blueprint: blueprintName, Path: config.FullPath, Phase: phase, Message: err.Error()}
+	// errors are formatted as "template: NAME:LINE:COL: message", see text/template/parse
+	prefix := fmt.Sprintf("template: %s:", config.Path)
+	var match []string
+	if strings.HasPrefix(err.Error(), prefix) {
+		match = templateErrorLocation.FindStringSubmatch(strings.TrimPrefix(err.Error(), prefix))
+	}
+	if match == nil {
+		templateError.Message = strings.TrimPrefix(templateError.Message, fmt.Sprintf("template: %s: ", config.Path))
+		return templateError
+	}
+	templateError.Line, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		// the column is the byte offset in the line
+		column, _ := strconv.Atoi(match[2])
+		templateError.Column = column + 1
+	}
+	templateError.Message = strings.TrimPrefix(match[3], fmt.Sprintf("executing %q ", config.Path))
+	templateError.Snippet = templateSnippet(content, templateError.Line, templateError.Column)
+	return templateError
+}
+
+// templateSnippet returns the lines around a line of a template, with a marker under the column when it is known
+func templateSnippet(content string, line int, column int) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	first, last := line-templateSnippetContext, line+templateSnippetContext
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+	snippet := &strings.Builder{}
+	for i := first; i <= last; i++ {
+		text := strings.TrimRight(lines[i-1], "\r")
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		fmt.Fprintf(snippet, "%s %*d | %s\n", marker, width, i, text)
+		if i == line && column > 0 && column <= len(text)+1 {
+			// keep the tabs of the line so that the marker is aligned
+			indent := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return r
+				}
+				return ' '
+			}, text[:column-1])
+			fmt.Fprintf(snippet, "  %*s | %s^\n", width, "", indent)
+		}
+	}
+	return strings.TrimSuffix(snippet.String(), "\n")
+}
+
+func (templateError *TemplateError) Error() string {
+	location := ""
+	if templateError.Line > 0 {
+		location = fmt.Sprintf(" line %d", templateError.Line)
+		if templateError.Column > 0 {
+			location += fmt.Sprintf(", column %d", templateError.Column)
+		}
+	}
+	message := fmt.Sprintf(
+		"cannot %s template of blueprint [%s] at [%s]%s: %s",
+		templateError.Phase, templateError.Blueprint, templateError.Path, location, templateError.Message,
+	)
+	if templateError.Snippet != "" {
+		message += "\n" + templateError.Snippet
+	}
+	return message
+}
+
+func (templateErrors TemplateErrors) Error() string {
+	if len(templateErrors) == 1 {
+		return templateErrors[0].Error()
+	}
+	messages := make([]string, len(templateErrors))
+	for i, templateError := range templateErrors {
+		messages[i] = templateError.Error()
+	}
+	return fmt.Sprintf("%d template errors found\n%s", len(templateErrors), strings.Join(messages, "\n\n"))
+}
+
+// findTemplateBlueprint returns the blueprint of the composition a template file belongs to
+func findTemplateBlueprint(composition []BlueprintInclusion, config TemplateConfig, templatePath string) string {
+	for _, inclusion := range composition {
+		for _, templateConfig := range inclusion.templateConfigs {
+			if templateConfig.FullPath == config.FullPath {
+				return inclusion.Blueprint
+			}
+		}
+	}
+	return templatePath
+}
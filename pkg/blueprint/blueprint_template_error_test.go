@@ -0,0 +1,105 @@
+package blueprint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTemplateError(t *testing.T) {
+	config := TemplateConfig{Path: "app.yaml.tmpl", FullPath: "aws/app/app.yaml.tmpl"}
+
+	t.Run("should locate parse errors on their line", func(t *testing.T) {
+		content := "a: 1\nb: 2\nc: {{ .Name | nosuchfunc }}\nd: 4\ne: 5\nf: 6\n"
+		templateError := newTemplateError("aws/app", config, content, templatePhaseParse, fmt.Errorf(`template: app.yaml.tmpl:3: function "nosuchfunc" not defined`))
+		assert.Equal(t, &TemplateError{
+			Blueprint: "aws/app",
+			Path:      "aws/app/app.yaml.tmpl",
+			Phase:     templatePhaseParse,
+			Line:      3,
+			Message:   `function "nosuchfunc" not defined`,
+			Snippet:   "  1 | a: 1\n  2 | b: 2\n> 3 | c: {{ .Name | nosuchfunc }}\n  4 | d: 4\n  5 | e: 5",
+		}, templateError)
+		assert.Equal(t, "cannot parse template of blueprint [aws/app] at [aws/app/app.yaml.tmpl] line 3: function \"nosuchfunc\" not defined\n"+templateError.Snippet, templateError.Error())
+	})
+
+	t.Run("should locate execution errors on their line and column", func(t *testing.T) {
+		content := "a: 1\n\tb: {{ index .Name 5 }}\n"
+		templateError := newTemplateError("aws/app", config, content, templatePhaseExecute, fmt.Errorf(`template: app.yaml.tmpl:2:7: executing "app.yaml.tmpl" at <index .Name 5>: error calling index: index out of range: 5`))
+		assert.Equal(t, 2, templateError.Line)
+		assert.Equal(t, 8, templateError.Column)
+		assert.Equal(t, "at <index .Name 5>: error calling index: index out of range: 5", templateError.Message)
+		assert.Equal(t, "  1 | a: 1\n> 2 | \tb: {{ index .Name 5 }}\n    | \t      ^", templateError.Snippet)
+	})
+
+	t.Run("should keep errors without location", func(t *testing.T) {
+		templateError := newTemplateError("aws/app", config, "", templatePhaseExecute, fmt.Errorf(`template: app.yaml.tmpl: "app.yaml.tmpl" is an incomplete or empty template`))
+		assert.Equal(t, 0, templateError.Line)
+		assert.Empty(t, templateError.Snippet)
+		assert.Equal(t, `cannot execute template of blueprint [aws/app] at [aws/app/app.yaml.tmpl]: "app.yaml.tmpl" is an incomplete or empty template`, templateError.Error())
+	})
+}
+
+func TestInstantiateBlueprintWithTemplateErrors(t *testing.T) {
+	repoDir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"app/blueprint.yaml": `
+apiVersion: xl/v2
+kind: Blueprint
+spec:
+  parameters:
+  - name: AppName
+    value: my-app
+  files:
+  - path: ok.yaml.tmpl
+  - path: parse.yaml.tmpl
+  includeAfter:
+  - blueprint: db
+`,
+		"app/ok.yaml.tmpl":    "name: {{.AppName}}",
+		"app/parse.yaml.tmpl": "name: {{.AppName}\n",
+		"db/blueprint.yaml": `
+apiVersion: xl/v2
+kind: Blueprint
+spec:
+  files:
+  - path: exec.yaml.tmpl
+`,
+		"db/exec.yaml.tmpl": "name: {{ index .AppName 10 }}\n",
+	})
+	defer cleanup()
+	blueprintContext, err := ConstructLocalBlueprintContext(repoDir)
+	require.Nil(t, err)
+
+	projectDir := filepath.Join(repoDir, "project")
+	gb := &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs"}
+	_, _, err = InstantiateBlueprint(
+		BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{}, StrictAnswers: true},
+		blueprintContext, gb, nil,
+	)
+	require.NotNil(t, err)
+	// nothing is written, not even the valid templates rendered before the broken ones
+	assert.Empty(t, gb.Files)
+	assert.Empty(t, stagingDirs(t, projectDir))
+	require.Nil(t, gb.Cleanup())
+
+	templateErrors, ok := err.(TemplateErrors)
+	require.True(t, ok)
+	require.Len(t, templateErrors, 2)
+	assert.Equal(t, "app", templateErrors[0].Blueprint)
+	assert.Equal(t, "app/parse.yaml.tmpl", templateErrors[0].Path)
+	assert.Equal(t, templatePhaseParse, templateErrors[0].Phase)
+	assert.Equal(t, 1, templateErrors[0].Line)
+	assert.Equal(t, "db", templateErrors[1].Blueprint)
+	assert.Equal(t, "db/exec.yaml.tmpl", templateErrors[1].Path)
+	assert.Equal(t, templatePhaseExecute, templateErrors[1].Phase)
+	assert.Equal(t, 1, templateErrors[1].Line)
+	assert.Equal(t, 10, templateErrors[1].Column)
+	assert.Contains(t, err.Error(), "2 template errors found\n")
+
+	_, err = os.Stat(filepath.Join(projectDir, "ok.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,476 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/local"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"github.com/xebialabs/yaml"
+)
+
+const testCasesDir = "__test__"
+
+var testCaseFileRegex = regexp.MustCompile(`^test-case-.+\.ya?ml$`)
+
+// BlueprintTestCase is a test case definition found in the __test__ directory of a blueprint
+type BlueprintTestCase struct {
+	AnswersFile       string                 `yaml:"answers-file"`
+	GoldenDir         string                 `yaml:"golden-dir"`
+	ExpectedFiles     []string               `yaml:"expected-files"`
+	ExpectedXlValues  map[string]interface{} `yaml:"expected-xl-values"`
+	ExpectedXlSecrets map[string]interface{} `yaml:"expected-xl-secrets"`
+}
+
+// BlueprintTestResult is the outcome of running a single blueprint test case
+type BlueprintTestResult struct {
+	Repository string        `json:"repository"`
+	Blueprint  string        `json:"blueprint"`
+	TestCase   string        `json:"testCase"`
+	Duration   time.Duration `json:"duration"`
+	Failures   []string      `json:"failures,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	// GoldenUpdated is set when the golden files of the test case were rewritten instead of compared
+	GoldenUpdated bool `json:"goldenUpdated,omitempty"`
+}
+
+// Passed returns true when the blueprint was generated and all expectations were met
+func (result *BlueprintTestResult) Passed() bool {
+	return result.Error == "" && len(result.Failures) == 0
+}
+
+// UseRepository makes the named repository from the defined repositories the active one
+func (blueprintContext *BlueprintContext) UseRepository(name string) error {
+	repo, err := blueprintContext.findRepository(name)
+	if err != nil {
+		return err
+	}
+	blueprintContext.ActiveRepo = repo
+	return nil
+}
+
+// findRepository returns the named repository from the defined repositories, ignoring case
+func (blueprintContext *BlueprintContext) findRepository(name string) (*repository.BlueprintRepository, error) {
+	for _, repo := range blueprintContext.DefinedRepos {
+		if strings.EqualFold((*repo).GetName(), name) {
+			return repo, nil
+		}
+	}
+	return nil, fmt.Errorf("repository '%s' is not defined", name)
+}
+
+// RunBlueprintTests runs the test cases of the given blueprints in the named repository, or in all defined repositories
+// when no repository name is given. All blueprints having test cases are tested when no blueprint path is given.
+// When updateGolden is set, the golden files of the test cases are rewritten with the generated output, which is only
+// possible in local repositories.
+func (blueprintContext *BlueprintContext) RunBlueprintTests(repositoryName string, blueprintPaths []string, updateGolden bool) ([]BlueprintTestResult, error) {
+	repos := blueprintContext.DefinedRepos
+	if repositoryName != "" {
+		repo, err := blueprintContext.findRepository(repositoryName)
+		if err != nil {
+			return nil, err
+		}
+		repos = []*repository.BlueprintRepository{repo}
+	}
+
+	results := make([]BlueprintTestResult, 0)
+	foundPaths := make(map[string]bool)
+	testedRepos := 0
+	for _, repo := range repos {
+		var localRepo *local.LocalBlueprintRepository
+		if updateGolden {
+			var isLocal bool
+			if localRepo, isLocal = (*repo).(*local.LocalBlueprintRepository); !isLocal {
+				util.Verbose("[test] Skipping repository %s, golden files can only be updated in a local repository\n", (*repo).GetName())
+				continue
+			}
+		}
+		testedRepos++
+
+		repoContext := &BlueprintContext{ActiveRepo: repo, DefinedRepos: blueprintContext.DefinedRepos}
+		repoResults, err := repoContext.runRepositoryTests(blueprintPaths, localRepo, foundPaths)
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: %s", (*repo).GetName(), err)
+		}
+		results = append(results, repoResults...)
+	}
+
+	if updateGolden && testedRepos == 0 {
+		return nil, fmt.Errorf("golden files can only be updated in a local repository")
+	}
+	for _, blueprintPath := range blueprintPaths {
+		if !foundPaths[blueprintPath] {
+			if repositoryName != "" {
+				return nil, fmt.Errorf("blueprint [%s] not found in repository %s", blueprintPath, repositoryName)
+			}
+			return nil, fmt.Errorf("blueprint [%s] not found in any repository", blueprintPath)
+		}
+	}
+	return results, nil
+}
+
+// runRepositoryTests runs the test cases of the given blueprints found in the active repository, or of all its
+// blueprints when no blueprint path is given. The paths of the blueprints found are marked in foundPaths.
+func (blueprintContext *BlueprintContext) runRepositoryTests(blueprintPaths []string, localRepo *local.LocalBlueprintRepository, foundPaths map[string]bool) ([]BlueprintTestResult, error) {
+	blueprints, err := blueprintContext.initCurrentRepoClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blueprintPaths) == 0 {
+		for blueprintPath := range blueprints {
+			blueprintPaths = append(blueprintPaths, blueprintPath)
+		}
+		sort.Strings(blueprintPaths)
+	}
+
+	var results []BlueprintTestResult
+	for _, blueprintPath := range blueprintPaths {
+		blueprint, exists := blueprints[blueprintPath]
+		if !exists {
+			continue
+		}
+		foundPaths[blueprintPath] = true
+		for _, testCasePath := range findTestCaseFiles(blueprint) {
+			results = append(results, blueprintContext.runTestCase(blueprint, testCasePath, localRepo))
+		}
+	}
+	return results, nil
+}
+
+func findTestCaseFiles(blueprint *models.BlueprintRemote) []string {
+	var testCasePaths []string
+	testDir := path.Join(filepath.ToSlash(blueprint.Path), testCasesDir)
+	for _, file := range blueprint.Files {
+		filePath := filepath.ToSlash(file.Path)
+		if path.Dir(filePath) == testDir && testCaseFileRegex.MatchString(path.Base(filePath)) {
+			testCasePaths = append(testCasePaths, filePath)
+		}
+	}
+	sort.Strings(testCasePaths)
+	return testCasePaths
+}
+
+func (blueprintContext *BlueprintContext) runTestCase(blueprint *models.BlueprintRemote, testCasePath string, localRepo *local.LocalBlueprintRepository) BlueprintTestResult {
+	result := BlueprintTestResult{
+		Repository: (*blueprintContext.ActiveRepo).GetName(),
+		Blueprint:  blueprint.Path,
+		TestCase:   path.Base(testCasePath),
+	}
+	start := time.Now()
+	err := blueprintContext.executeTestCase(blueprint, testCasePath, localRepo, &result)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// readTestCaseAnswers reads the answers file of a test case like --answers does, it is fetched from the repository to a
+// temporary file first
+func (blueprintContext *BlueprintContext) readTestCaseAnswers(answersPath string) (map[string]string, error) {
+	content, err := blueprintContext.fetchFileContents(answersPath, false)
+	if err != nil {
+		return nil, err
+	}
+	answersFile, err := ioutil.TempFile("", "blueprint-answers")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(answersFile.Name())
+	_, err = answersFile.Write(*content)
+	if closeErr := answersFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return GetValuesFromAnswersFile(answersFile.Name())
+}
+
+// executeTestCase generates the blueprint non-interactively in a temporary directory and checks the output against the expectations
+func (blueprintContext *BlueprintContext) executeTestCase(blueprint *models.BlueprintRemote, testCasePath string, localRepo *local.LocalBlueprintRepository, result *BlueprintTestResult) error {
+	util.Verbose("[test] Running test case %s\n", testCasePath)
+	content, err := blueprintContext.fetchFileContents(testCasePath, false)
+	if err != nil {
+		return err
+	}
+	testCase := BlueprintTestCase{}
+	if err := yaml.UnmarshalStrict(*content, &testCase); err != nil {
+		return fmt.Errorf("invalid test case definition: %s", err)
+	}
+
+	answers := make(map[string]string)
+	if testCase.AnswersFile != "" {
+		answers, err = blueprintContext.readTestCaseAnswers(path.Join(path.Dir(testCasePath), testCase.AnswersFile))
+		if err != nil {
+			return fmt.Errorf("invalid answers file %s: %s", testCase.AnswersFile, err)
+		}
+	}
+
+	outputDir, err := ioutil.TempDir("", "blueprint-test")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outputDir)
+
+	params := BlueprintParams{
+		TemplatePath:       blueprint.Path,
+		AnswersMap:         answers,
+		StrictAnswers:      true,
+		UseDefaultsAsValue: true,
+		SkipFinalPrompt:    true,
+	}
+	generatedBlueprint := &GeneratedBlueprint{BaseDir: outputDir, OutputDir: models.BlueprintOutputDir}
+	if _, _, err := InstantiateBlueprint(params, blueprintContext, generatedBlueprint, nil); err != nil {
+		generatedBlueprint.Cleanup()
+		return err
+	}
+
+	for _, expectedFile := range testCase.ExpectedFiles {
+		if !util.PathExists(generatedBlueprint.OutputPath(expectedFile), false) {
+			result.Failures = append(result.Failures, fmt.Sprintf("expected file %s is not generated", expectedFile))
+		}
+	}
+	result.Failures = append(result.Failures, checkExpectedValues(outputDir, filepath.Join(generatedBlueprint.OutputDir, valuesFile), testCase.ExpectedXlValues)...)
+	result.Failures = append(result.Failures, checkExpectedValues(outputDir, filepath.Join(generatedBlueprint.OutputDir, secretsFile), testCase.ExpectedXlSecrets)...)
+
+	if testCase.GoldenDir != "" {
+		goldenPath := path.Join(path.Dir(testCasePath), testCase.GoldenDir)
+		if path.IsAbs(testCase.GoldenDir) || !strings.HasPrefix(goldenPath, path.Dir(testCasePath)+"/") {
+			return fmt.Errorf("golden-dir %s must be a directory within %s", testCase.GoldenDir, testCasesDir)
+		}
+		generatedFiles := getGeneratedFilePaths(generatedBlueprint)
+		if localRepo != nil {
+			result.GoldenUpdated = true
+			return updateGoldenFiles(filepath.Join(localRepo.Path, filepath.FromSlash(goldenPath)), outputDir, generatedFiles)
+		}
+		failures, err := blueprintContext.compareGoldenFiles(blueprint, goldenPath, outputDir, generatedFiles)
+		if err != nil {
+			return err
+		}
+		result.Failures = append(result.Failures, failures...)
+	}
+	return nil
+}
+
+// getGeneratedFilePaths returns the slash separated paths of the generated files relative to the base directory,
+// directories and manifest excluded
+func getGeneratedFilePaths(generatedBlueprint *GeneratedBlueprint) []string {
+	var filePaths []string
+	for _, generatedFile := range generatedBlueprint.GeneratedFiles {
+		if isManifestFile(generatedBlueprint, generatedFile) {
+			continue
+		}
+		if isDir, _ := isDirectory(generatedFile); isDir {
+			continue
+		}
+		filePath, err := filepath.Rel(generatedBlueprint.OutputPath(""), generatedFile)
+		if err != nil {
+			continue
+		}
+		if !util.IsStringInSlice(filepath.ToSlash(filePath), filePaths) {
+			filePaths = append(filePaths, filepath.ToSlash(filePath))
+		}
+	}
+	sort.Strings(filePaths)
+	return filePaths
+}
+
+// compareGoldenFiles compares the files generated in outputDir with the golden files of the test case, both ways
+func (blueprintContext *BlueprintContext) compareGoldenFiles(blueprint *models.BlueprintRemote, goldenPath string, outputDir string, generatedFiles []string) ([]string, error) {
+	goldenFiles := make(map[string]string)
+	for _, file := range blueprint.Files {
+		filePath := filepath.ToSlash(file.Path)
+		if strings.HasPrefix(filePath, goldenPath+"/") {
+			goldenFiles[strings.TrimPrefix(filePath, goldenPath+"/")] = filePath
+		}
+	}
+	if len(goldenFiles) == 0 {
+		return []string{fmt.Sprintf("golden directory %s is empty or missing, run with golden file update to create it", goldenPath)}, nil
+	}
+
+	var failures []string
+	for _, generatedFile := range generatedFiles {
+		goldenFile, exists := goldenFiles[generatedFile]
+		if !exists {
+			failures = append(failures, fmt.Sprintf("generated file %s has no golden file", generatedFile))
+			continue
+		}
+		delete(goldenFiles, generatedFile)
+
+		expected, err := blueprintContext.fetchFileContents(goldenFile, false)
+		if err != nil {
+			return nil, err
+		}
+		actual, err := ioutil.ReadFile(filepath.Join(outputDir, filepath.FromSlash(generatedFile)))
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(*expected, actual) {
+			continue
+		}
+		if isBinaryContent(*expected) || isBinaryContent(actual) {
+			failures = append(failures, fmt.Sprintf("generated file %s differs from its golden file", generatedFile))
+		} else {
+			diff := util.UnifiedDiff(path.Join("golden", generatedFile), generatedFile, string(*expected), string(actual), 3)
+			failures = append(failures, fmt.Sprintf("generated file %s differs from its golden file:\n%s", generatedFile, strings.TrimSuffix(diff, "\n")))
+		}
+	}
+
+	var missingFiles []string
+	for goldenFile := range goldenFiles {
+		missingFiles = append(missingFiles, goldenFile)
+	}
+	sort.Strings(missingFiles)
+	for _, missingFile := range missingFiles {
+		failures = append(failures, fmt.Sprintf("golden file %s is not generated", missingFile))
+	}
+	return failures, nil
+}
+
+// updateGoldenFiles replaces the contents of the golden directory with the files generated in outputDir
+func updateGoldenFiles(goldenDir string, outputDir string, generatedFiles []string) error {
+	util.Verbose("[test] Updating golden files in %s\n", goldenDir)
+	if err := os.RemoveAll(goldenDir); err != nil {
+		return err
+	}
+	for _, generatedFile := range generatedFiles {
+		content, err := ioutil.ReadFile(filepath.Join(outputDir, filepath.FromSlash(generatedFile)))
+		if err != nil {
+			return err
+		}
+		goldenFile := filepath.Join(goldenDir, filepath.FromSlash(generatedFile))
+		if err := os.MkdirAll(filepath.Dir(goldenFile), os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(goldenFile, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content, 0) != -1
+}
+
+// checkExpectedValues compares the values of a properties file generated in outputDir with the expected values
+func checkExpectedValues(outputDir string, fileName string, expectedValues map[string]interface{}) []string {
+	if len(expectedValues) == 0 {
+		return nil
+	}
+	props, err := properties.LoadFile(filepath.Join(outputDir, fileName), properties.UTF8)
+	if err != nil {
+		return []string{fmt.Sprintf("could not read %s: %s", fileName, err)}
+	}
+
+	var keys []string
+	for key := range expectedValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var failures []string
+	for _, key := range keys {
+		expected := fmt.Sprint(expectedValues[key])
+		actual, exists := props.Get(key)
+		if !exists {
+			failures = append(failures, fmt.Sprintf("expected %s to contain %s", fileName, key))
+		} else if actual != expected {
+			failures = append(failures, fmt.Sprintf("expected %s in %s to be [%s] but was [%s]", key, fileName, expected, actual))
+		}
+	}
+	return failures
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the test results as JUnit XML, with a test suite per blueprint named after its repository
+func WriteJUnitReport(results []BlueprintTestResult, w io.Writer) error {
+	report := junitTestSuites{}
+	suiteIndex := make(map[string]int)
+	suiteTimes := make(map[string]time.Duration)
+	for _, result := range results {
+		suiteName := path.Join(result.Repository, result.Blueprint)
+		index, exists := suiteIndex[suiteName]
+		if !exists {
+			index = len(report.Suites)
+			suiteIndex[suiteName] = index
+			report.Suites = append(report.Suites, junitTestSuite{Name: suiteName})
+		}
+		suite := &report.Suites[index]
+
+		testCase := junitTestCase{
+			Name:      result.TestCase,
+			ClassName: suiteName,
+			Time:      fmt.Sprintf("%.3f", result.Duration.Seconds()),
+		}
+		if result.Error != "" {
+			testCase.Error = &junitMessage{Message: "blueprint generation failed", Contents: result.Error}
+			suite.Errors++
+			report.Errors++
+		} else if len(result.Failures) > 0 {
+			testCase.Failure = &junitMessage{Message: fmt.Sprintf("%d expectation(s) failed", len(result.Failures)), Contents: strings.Join(result.Failures, "\n")}
+			suite.Failures++
+			report.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		report.Tests++
+		suiteTimes[suiteName] += result.Duration
+		suite.Time = fmt.Sprintf("%.3f", suiteTimes[suiteName].Seconds())
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
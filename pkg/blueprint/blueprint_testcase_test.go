@@ -0,0 +1,159 @@
+package blueprint
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository/local"
+)
+
+func getTestCaseBlueprintContext(t *testing.T) *BlueprintContext {
+	blueprintContext, err := ConstructLocalBlueprintContext(GetTestTemplateDir("test-case-repo"))
+	require.Nil(t, err)
+	return blueprintContext
+}
+
+func TestBlueprintContext_RunBlueprintTests(t *testing.T) {
+	t.Run("should run all test cases of the repository", func(t *testing.T) {
+		blueprintContext := getTestCaseBlueprintContext(t)
+		workingDir, _ := os.Getwd()
+		skipFinalPrompt := SkipFinalPrompt
+
+		results, err := blueprintContext.RunBlueprintTests("", nil, false)
+		require.Nil(t, err)
+		require.Len(t, results, 3)
+
+		currentDir, _ := os.Getwd()
+		assert.Equal(t, workingDir, currentDir)
+		assert.Equal(t, skipFinalPrompt, SkipFinalPrompt)
+
+		assert.Equal(t, "cmd-arg", results[0].Repository)
+		assert.Equal(t, "app", results[0].Blueprint)
+		assert.Equal(t, "test-case-1.yaml", results[0].TestCase)
+		assert.True(t, results[0].Passed())
+
+		assert.Equal(t, "test-case-2.yaml", results[1].TestCase)
+		assert.False(t, results[1].Passed())
+		assert.Empty(t, results[1].Error)
+		assert.Equal(t, []string{
+			"expected file other.yaml is not generated",
+			"expected AppName in xebialabs/values.xlvals to be [other-app] but was [my-app]",
+		}, results[1].Failures)
+
+		assert.Equal(t, "test-case-3.yaml", results[2].TestCase)
+		assert.Equal(t, "variable with name [AppName] could not be found in answers file", results[2].Error)
+	})
+
+	t.Run("should fail for unknown blueprint", func(t *testing.T) {
+		blueprintContext := getTestCaseBlueprintContext(t)
+
+		_, err := blueprintContext.RunBlueprintTests("", []string{"unknown"}, false)
+		require.NotNil(t, err)
+		assert.Equal(t, "blueprint [unknown] not found in any repository", err.Error())
+
+		_, err = blueprintContext.RunBlueprintTests("other", nil, false)
+		require.NotNil(t, err)
+		assert.Equal(t, "repository 'other' is not defined", err.Error())
+	})
+
+	t.Run("should run the test cases of all defined repositories", func(t *testing.T) {
+		blueprintContext := getTestCaseBlueprintContext(t)
+		var otherRepo repository.BlueprintRepository
+		otherRepo, err := local.NewLocalBlueprintRepository(map[string]string{"name": "other-repo", "path": GetTestTemplateDir("test-case-repo")})
+		require.Nil(t, err)
+		blueprintContext.DefinedRepos = append(blueprintContext.DefinedRepos, &otherRepo)
+
+		results, err := blueprintContext.RunBlueprintTests("", []string{"app"}, false)
+		require.Nil(t, err)
+		require.Len(t, results, 6)
+		assert.Equal(t, "cmd-arg", results[0].Repository)
+		assert.Equal(t, "other-repo", results[3].Repository)
+		assert.Equal(t, "app", results[3].Blueprint)
+		assert.Equal(t, "test-case-1.yaml", results[3].TestCase)
+
+		results, err = blueprintContext.RunBlueprintTests("other-repo", nil, false)
+		require.Nil(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "other-repo", results[0].Repository)
+	})
+}
+
+func TestBlueprintContext_RunBlueprintTests_GoldenFiles(t *testing.T) {
+	// the golden files are written next to the test case, in a copy of the blueprint
+	dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"app/blueprint.yaml":            GetFileContent(filepath.Join(GetTestTemplateDir("test-case-repo"), "app", "blueprint.yaml")),
+		"app/app.yaml.tmpl":             "name: {{.AppName}}\nkind: app",
+		"app/__test__/answers.yaml":     "AppName: my-app",
+		"app/__test__/test-case-1.yaml": "answers-file: answers.yaml\ngolden-dir: golden",
+	})
+	defer cleanup()
+	blueprintContext, err := ConstructLocalBlueprintContext(dir)
+	require.Nil(t, err)
+	goldenDir := filepath.Join(dir, "app", "__test__", "golden")
+
+	t.Run("should report missing golden files", func(t *testing.T) {
+		results, err := blueprintContext.RunBlueprintTests("", []string{"app"}, false)
+		require.Nil(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, []string{"golden directory app/__test__/golden is empty or missing, run with golden file update to create it"}, results[0].Failures)
+	})
+
+	t.Run("should write golden files in update mode", func(t *testing.T) {
+		results, err := blueprintContext.RunBlueprintTests("", []string{"app"}, true)
+		require.Nil(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Passed())
+		assert.True(t, results[0].GoldenUpdated)
+		assert.Equal(t, "name: my-app\nkind: app", GetFileContent(filepath.Join(goldenDir, "app.yaml")))
+		assert.FileExists(t, filepath.Join(goldenDir, "xebialabs", "values.xlvals"))
+		assert.FileExists(t, filepath.Join(goldenDir, "xebialabs", "secrets.xlvals"))
+		assert.FileExists(t, filepath.Join(goldenDir, "xebialabs", ".gitignore"))
+	})
+
+	t.Run("should pass when output matches golden files", func(t *testing.T) {
+		results, err := blueprintContext.RunBlueprintTests("", []string{"app"}, false)
+		require.Nil(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Passed())
+		assert.False(t, results[0].GoldenUpdated)
+	})
+
+	t.Run("should report differences as unified diff", func(t *testing.T) {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(goldenDir, "app.yaml"), []byte("name: old-app\nkind: app"), 0644))
+		require.Nil(t, ioutil.WriteFile(filepath.Join(goldenDir, "extra.yaml"), []byte("extra"), 0644))
+		require.Nil(t, os.Remove(filepath.Join(goldenDir, "xebialabs", ".gitignore")))
+
+		results, err := blueprintContext.RunBlueprintTests("", []string{"app"}, false)
+		require.Nil(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, []string{
+			"generated file app.yaml differs from its golden file:\n--- golden/app.yaml\n+++ app.yaml\n@@ -1,2 +1,2 @@\n-name: old-app\n+name: my-app\n kind: app",
+			"generated file xebialabs/.gitignore has no golden file",
+			"golden file extra.yaml is not generated",
+		}, results[0].Failures)
+	})
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	results := []BlueprintTestResult{
+		{Repository: "repo", Blueprint: "app", TestCase: "test-case-1.yaml"},
+		{Repository: "repo", Blueprint: "app", TestCase: "test-case-2.yaml", Failures: []string{"expected file a.yaml is not generated"}},
+		{Repository: "repo", Blueprint: "other", TestCase: "test-case-1.yaml", Error: "boom"},
+		{Repository: "other-repo", Blueprint: "app", TestCase: "test-case-1.yaml"},
+	}
+	var out bytes.Buffer
+	require.Nil(t, WriteJUnitReport(results, &out))
+
+	report := out.String()
+	assert.Contains(t, report, `<testsuites tests="4" failures="1" errors="1">`)
+	assert.Contains(t, report, `<testsuite name="repo/app" tests="2" failures="1" errors="0" time="0.000">`)
+	assert.Contains(t, report, `<testsuite name="other-repo/app" tests="1" failures="0" errors="0" time="0.000">`)
+	assert.Contains(t, report, `<failure message="1 expectation(s) failed">expected file a.yaml is not generated</failure>`)
+	assert.Contains(t, report, `<error message="blueprint generation failed">boom</error>`)
+}
@@ -0,0 +1,208 @@
+package blueprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	survey "gopkg.in/AlecAivazis/survey.v1"
+)
+
+// BlueprintUI serves a web page with the form of a blueprint, and generates the blueprint from the submitted form
+type BlueprintUI struct {
+	sync.Mutex
+	blueprintContext *BlueprintContext
+	form             *BlueprintForm
+	outputDir        string
+	mux              *http.ServeMux
+}
+
+// FormRequest is the body of the form and generate requests of the UI
+type FormRequest struct {
+	Answers   map[string]string `json:"answers"`
+	OutputDir string            `json:"outputDir"`
+	Overwrite bool              `json:"overwrite"`
+}
+
+type formResponse struct {
+	*FormState
+	OutputDir string `json:"outputDir"`
+}
+
+type generateResponse struct {
+	OutputDir string           `json:"outputDir"`
+	Result    *BlueprintResult `json:"result"`
+}
+
+// NewBlueprintUI creates the HTTP handler of the form of a blueprint, the blueprint is generated in outputDir unless
+// another directory is chosen in the form
+func NewBlueprintUI(blueprintContext *BlueprintContext, templatePath string, outputDir string) (*BlueprintUI, error) {
+	form, err := blueprintContext.NewBlueprintForm(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	// fail on start when the form cannot be built, rather than on the first request
+	if _, err := form.Evaluate(nil); err != nil {
+		return nil, err
+	}
+	ui := &BlueprintUI{blueprintContext: blueprintContext, form: form, outputDir: outputDir, mux: http.NewServeMux()}
+	ui.mux.HandleFunc("/", ui.servePage)
+	ui.mux.HandleFunc("/api/form", ui.handle(ui.evaluateForm))
+	ui.mux.HandleFunc("/api/generate", ui.handle(ui.generateBlueprint))
+	return ui, nil
+}
+
+func (ui *BlueprintUI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ui.mux.ServeHTTP(w, r)
+}
+
+// handle reads the form request of a POST request from the page of the UI, requests are handled one at a time
+func (ui *BlueprintUI) handle(handler func(http.ResponseWriter, *FormRequest)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		util.Verbose("[ui] %s %s\n", r.Method, r.URL)
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		if status, err := checkSameOrigin(r); err != nil {
+			writeJSONError(w, status, err)
+			return
+		}
+		request := &FormRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("request body is not valid: %s", err))
+			return
+		}
+		ui.Lock()
+		defer ui.Unlock()
+		handler(w, request)
+	}
+}
+
+// checkSameOrigin rejects the requests that other web pages opened in the browser can send to the UI. Requests that
+// are not JSON would not need a CORS preflight, the origin must be the UI itself, and the host must be localhost or an
+// IP address so that a domain name resolved to the UI cannot be used to bypass the origin check.
+func checkSameOrigin(r *http.Request) (int, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("content type must be application/json")
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if host != "localhost" && net.ParseIP(strings.Trim(host, "[]")) == nil {
+		return http.StatusForbidden, fmt.Errorf("host %s is not allowed, open the page with localhost or an IP address", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if originURL, err := url.Parse(origin); err != nil || originURL.Host != r.Host {
+			return http.StatusForbidden, fmt.Errorf("origin %s is not allowed", origin)
+		}
+	}
+	return 0, nil
+}
+
+// servePage handles GET /
+func (ui *BlueprintUI) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, formPage)
+}
+
+// evaluateForm handles POST /api/form and returns the fields of the form for the answers given so far
+func (ui *BlueprintUI) evaluateForm(w http.ResponseWriter, request *FormRequest) {
+	state, err := ui.form.Evaluate(request.Answers)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, formResponse{FormState: state, OutputDir: ui.outputDir})
+}
+
+// generateBlueprint handles POST /api/generate, the form is returned with its errors when it is not valid
+func (ui *BlueprintUI) generateBlueprint(w http.ResponseWriter, request *FormRequest) {
+	state, err := ui.form.Evaluate(request.Answers)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if !state.Valid {
+		writeJSON(w, http.StatusUnprocessableEntity, formResponse{FormState: state, OutputDir: ui.outputDir})
+		return
+	}
+	outputDir := request.OutputDir
+	if outputDir == "" {
+		outputDir = ui.outputDir
+	}
+	result, err := ui.generate(state, outputDir, request.Overwrite)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	util.Info("Blueprint %s generated in %s\n", ui.form.TemplatePath, outputDir)
+	writeJSON(w, http.StatusOK, generateResponse{OutputDir: outputDir, Result: result})
+}
+
+// generate generates the blueprint with the answers of a valid form, uploaded files are passed as temporary files
+func (ui *BlueprintUI) generate(state *FormState, outputDir string, overwrite bool) (*BlueprintResult, error) {
+	uploadDir, err := ioutil.TempDir("", "blueprint-ui")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(uploadDir)
+
+	answers := make(map[string]string)
+	for _, field := range state.Fields {
+		if !field.Visible {
+			continue
+		}
+		answer := state.answers[field.Name]
+		if field.Type == TypeFile || field.Type == TypeSecretFile {
+			filePath := filepath.Join(uploadDir, field.Name)
+			if err := ioutil.WriteFile(filePath, []byte(answer), 0600); err != nil {
+				return nil, err
+			}
+			answer = filePath
+		}
+		answers[field.Name] = answer
+	}
+
+	// the form is complete and validated, answers left empty are kept empty instead of being asked
+	params := BlueprintParams{
+		TemplatePath:    ui.form.TemplatePath,
+		AnswersMap:      answers,
+		OnConflict:      ConflictPolicyFail,
+		SkipFinalPrompt: true,
+		SkipUserInput:   true,
+	}
+	if overwrite {
+		params.OnConflict = ConflictPolicyOverwrite
+	}
+	generatedBlueprint := &GeneratedBlueprint{BaseDir: outputDir, OutputDir: models.BlueprintOutputDir}
+	var preparedData *PreparedData
+	var blueprintDoc *BlueprintConfig
+	err = withoutPrompts(func(surveyOpts ...survey.AskOpt) error {
+		var err error
+		preparedData, blueprintDoc, err = InstantiateBlueprint(params, ui.blueprintContext, generatedBlueprint, nil, surveyOpts...)
+		return err
+	})
+	if err != nil {
+		generatedBlueprint.Cleanup()
+		return nil, err
+	}
+	return NewBlueprintResult(generatedBlueprint, blueprintDoc, preparedData), nil
+}
@@ -0,0 +1,205 @@
+package blueprint
+
+// formPage is the page of the blueprint UI, the form is built from the fields returned by /api/form and evaluated
+// again on the server whenever an answer changes, so that promptIf conditions and validations are always up to date
+const formPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Blueprint</title>
+<style>
+  body { font-family: sans-serif; max-width: 48em; margin: 2em auto; padding: 0 1em; color: #222; }
+  .field { margin: 1em 0; }
+  .field label { display: block; font-weight: bold; margin-bottom: .3em; }
+  .field input[type=text], .field input[type=password], .field select, .field textarea, #outputDir { width: 100%; box-sizing: border-box; padding: .4em; }
+  .field textarea { min-height: 6em; }
+  .help { color: #666; font-size: .9em; margin-top: .2em; }
+  .error { color: #b00; font-size: .9em; margin-top: .2em; }
+  .blueprint { color: #999; font-size: .8em; font-weight: normal; }
+  #message { margin: 1em 0; }
+  #result li { font-family: monospace; }
+  button { padding: .5em 1.5em; }
+</style>
+</head>
+<body>
+<h1 id="title">Blueprint</h1>
+<p id="description"></p>
+<form id="form">
+  <div id="fields"></div>
+  <div class="field">
+    <label for="outputDir">Output directory</label>
+    <input type="text" id="outputDir">
+    <label><input type="checkbox" id="overwrite"> Overwrite existing files</label>
+  </div>
+  <button type="submit" id="submit">Generate</button>
+</form>
+<div id="message"></div>
+<ul id="result"></ul>
+<pre id="instructions"></pre>
+<script>
+"use strict";
+const answers = {};
+const touched = {};
+const inputs = [];
+let submitted = false;
+let timer = null;
+
+function post(url, body) {
+  return fetch(url, {method: "POST", headers: {"Content-Type": "application/json"}, body: JSON.stringify(body)})
+    .then(response => response.json().then(data => ({status: response.status, data: data})));
+}
+
+function element(tag, props, children) {
+  const el = Object.assign(document.createElement(tag), props || {});
+  (children || []).forEach(child => el.appendChild(child));
+  return el;
+}
+
+function changed(name, value) {
+  answers[name] = value;
+  touched[name] = true;
+  clearTimeout(timer);
+  timer = setTimeout(refresh, 250);
+}
+
+function setOptions(select, field) {
+  const current = field.name in answers ? answers[field.name] : String(field.default);
+  const options = field.options || [];
+  select.textContent = "";
+  options.forEach(option => select.appendChild(element("option", {
+    value: option.value,
+    textContent: option.label ? option.value + " (" + option.label + ")" : option.value,
+    selected: option.value === current
+  })));
+}
+
+function createInput(field, id) {
+  let input;
+  switch (field.type) {
+  case "Select":
+    input = element("select", {id: id});
+    setOptions(input, field);
+    input.addEventListener("change", () => changed(field.name, input.value));
+    break;
+  case "Confirm":
+    input = element("input", {type: "checkbox", id: id, checked: field.default === true});
+    input.addEventListener("change", () => changed(field.name, String(input.checked)));
+    break;
+  case "File":
+  case "SecretFile":
+    input = element("input", {type: "file", id: id});
+    input.addEventListener("change", () => {
+      const reader = new FileReader();
+      reader.onload = () => changed(field.name, reader.result);
+      if (input.files.length > 0) {
+        reader.readAsText(input.files[0]);
+      } else {
+        changed(field.name, "");
+      }
+    });
+    break;
+  case "Editor":
+  case "SecretEditor":
+    input = element("textarea", {id: id, value: String(field.default)});
+    input.addEventListener("input", () => changed(field.name, input.value));
+    break;
+  default:
+    input = element("input", {type: field.secret ? "password" : "text", id: id, value: String(field.default)});
+    input.addEventListener("input", () => changed(field.name, input.value));
+  }
+  return input;
+}
+
+function build(form) {
+  document.title = form.name || form.blueprint;
+  document.getElementById("title").textContent = form.name || form.blueprint;
+  document.getElementById("description").textContent = form.description || "";
+  document.getElementById("outputDir").value = form.outputDir;
+  const container = document.getElementById("fields");
+  form.fields.forEach((field, i) => {
+    const id = "field-" + i;
+    const input = createInput(field, id);
+    const error = element("div", {className: "error"});
+    const label = element("label", {htmlFor: id, textContent: field.prompt + " "}, [
+      element("span", {className: "blueprint", textContent: field.blueprint})
+    ]);
+    const help = element("div", {className: "help", textContent: field.description || ""});
+    const div = element("div", {className: "field"}, [label, input, help, error]);
+    container.appendChild(div);
+    inputs.push({div: div, input: input, error: error});
+  });
+  update(form);
+}
+
+function update(form) {
+  form.fields.forEach((field, i) => {
+    const item = inputs[i];
+    item.div.hidden = !field.visible;
+    if (field.type === "Select") {
+      setOptions(item.input, field);
+    }
+    const showError = field.visible && field.error && (submitted || touched[field.name]);
+    item.error.textContent = showError ? field.error : "";
+  });
+}
+
+function refresh() {
+  return post("/api/form", {answers: answers}).then(response => {
+    if (response.status === 200) {
+      update(response.data);
+    } else {
+      showMessage(response.data.error, true);
+    }
+  });
+}
+
+function showMessage(text, isError) {
+  const message = document.getElementById("message");
+  message.textContent = text;
+  message.className = isError ? "error" : "";
+}
+
+document.getElementById("form").addEventListener("submit", event => {
+  event.preventDefault();
+  submitted = true;
+  clearTimeout(timer);
+  const button = document.getElementById("submit");
+  button.disabled = true;
+  document.getElementById("result").textContent = "";
+  document.getElementById("instructions").textContent = "";
+  post("/api/generate", {
+    answers: answers,
+    outputDir: document.getElementById("outputDir").value,
+    overwrite: document.getElementById("overwrite").checked
+  }).then(response => {
+    button.disabled = false;
+    if (response.status === 200) {
+      showMessage("Blueprint generated in " + response.data.outputDir, false);
+      const result = document.getElementById("result");
+      response.data.result.files.forEach(file => {
+        result.appendChild(element("li", {textContent: file.status + " " + file.path + (file.reason ? " (" + file.reason + ")" : "")}));
+      });
+      document.getElementById("instructions").textContent = response.data.result.instructions || "";
+    } else if (response.data.fields) {
+      update(response.data);
+      showMessage("Some answers are not valid", true);
+    } else {
+      showMessage(response.data.error, true);
+    }
+  }, error => {
+    button.disabled = false;
+    showMessage(String(error), true);
+  });
+});
+
+post("/api/form", {answers: answers}).then(response => {
+  if (response.status === 200) {
+    build(response.data);
+  } else {
+    showMessage(response.data.error, true);
+  }
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,199 @@
+package blueprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/magiconair/properties"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"gopkg.in/AlecAivazis/survey.v1"
+)
+
+// statuses of the project files after an upgrade
+const (
+	UpgradeStatusCreated   = "created"
+	UpgradeStatusUpdated   = "updated"
+	UpgradeStatusMerged    = "merged"
+	UpgradeStatusConflict  = "conflict"
+	UpgradeStatusUnchanged = "unchanged"
+	UpgradeStatusKept      = "kept"
+	UpgradeStatusRemoved   = "removed"
+)
+
+// UpgradeFile is a project file with what happened to it, or would happen to it in dry-run mode, during an upgrade
+type UpgradeFile struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	// Diff shows the changes of the blueprint that could not be merged into a kept file
+	Diff string `json:"diff,omitempty"`
+}
+
+// UpgradeBlueprint generates the blueprint of the manifest again with the answers used before, and applies the changes
+// since the previous generation to the project in the base directory of generatedBlueprint.
+// Files not edited since they were generated are replaced, edited files are merged with the changes of the blueprint,
+// overlapping changes are marked as conflict in the file.
+func UpgradeBlueprint(
+	blueprintContext *BlueprintContext,
+	generatedBlueprint *GeneratedBlueprint,
+	manifest *BlueprintManifest,
+	templatePath string,
+	surveyOpts ...survey.AskOpt,
+) ([]UpgradeFile, error) {
+	answers, err := getUpgradeAnswers(generatedBlueprint, manifest)
+	if err != nil {
+		return nil, err
+	}
+	if templatePath == "" {
+		templatePath = manifest.Blueprint
+	}
+
+	// render the new version without touching the project, only questions without an answer are asked
+	rendered := &GeneratedBlueprint{BaseDir: generatedBlueprint.BaseDir, OutputDir: generatedBlueprint.OutputDir, DryRun: true}
+	params := BlueprintParams{TemplatePath: templatePath, AnswersMap: answers, SkipFinalPrompt: true}
+	if _, _, err := InstantiateBlueprint(params, blueprintContext, rendered, nil, surveyOpts...); err != nil {
+		return nil, err
+	}
+
+	var results []UpgradeFile
+	renderedFiles := make(map[string]bool)
+	for _, file := range rendered.Files {
+		if file.Status == FileStatusSkipped {
+			continue
+		}
+		renderedFiles[filepath.ToSlash(file.Path)] = true
+		result, err := upgradeFile(generatedBlueprint, manifest, file, isInOutputDir(rendered, file.Path))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	// files no longer generated are removed, unless they were edited
+	for _, previous := range manifest.Files {
+		if renderedFiles[previous.Path] {
+			continue
+		}
+		result, err := removeUpgradedFile(generatedBlueprint, previous)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+
+	if generatedBlueprint.DryRun {
+		return results, nil
+	}
+	// replace the manifest and the copies of the generated files with the ones of the new version
+	generatedBlueprint.OutputDir = rendered.OutputDir
+	generatedBlueprint.Manifest = rendered.Manifest
+	for _, previous := range manifest.Files {
+		if renderedFiles[previous.Path] {
+			continue
+		}
+		basePath := generatedBlueprint.OutputPath(filepath.Join(generatedBlueprint.OutputDir, manifestBaseDir, filepath.FromSlash(previous.Path)))
+		if err := generatedBlueprint.removeFile(basePath); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeManifest(generatedBlueprint, rendered.Manifest, rendered.Files); err != nil {
+		return nil, err
+	}
+	if err := generatedBlueprint.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// getUpgradeAnswers returns the answers of the manifest together with the secrets saved in the project
+func getUpgradeAnswers(generatedBlueprint *GeneratedBlueprint, manifest *BlueprintManifest) (map[string]string, error) {
+	answers := make(map[string]string)
+	for name, value := range manifest.Answers {
+		answers[name] = value
+	}
+	secretsPath := generatedBlueprint.OutputPath(filepath.Join(generatedBlueprint.OutputDir, secretsFile))
+	if util.PathExists(secretsPath, false) {
+		secrets, err := properties.LoadFile(secretsPath, properties.UTF8)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read secrets from %s: %s", secretsPath, err)
+		}
+		for _, name := range secrets.Keys() {
+			answers[name] = secrets.GetString(name, "")
+		}
+	}
+	return answers, nil
+}
+
+// upgradeFile applies the new version of a generated file to the project file
+func upgradeFile(generatedBlueprint *GeneratedBlueprint, manifest *BlueprintManifest, file FileRecord, generatedFromAnswers bool) (UpgradeFile, error) {
+	result := UpgradeFile{Path: file.Path}
+	previous := manifest.findFile(file.Path)
+	local, err := ioutil.ReadFile(generatedBlueprint.OutputPath(file.Path))
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	content := file.Content
+	switch {
+	case exists && string(local) == file.Content:
+		result.Status = UpgradeStatusUnchanged
+		return result, nil
+	case generatedFromAnswers:
+		result.Status = UpgradeStatusUpdated
+	case !exists && previous != nil:
+		result.Status = UpgradeStatusKept
+		result.Message = "deleted since it was generated, not restored"
+		return result, nil
+	case !exists:
+		result.Status = UpgradeStatusCreated
+	case previous != nil && hashContent(string(local)) == previous.SHA256:
+		result.Status = UpgradeStatusUpdated
+	default:
+		// the file was edited, or it was not generated before but exists already
+		base, hasBase := "", false
+		if previous != nil {
+			base, hasBase = readManifestBase(generatedBlueprint, file.Path)
+		}
+		if !hasBase {
+			// without the generated version the changes cannot be told apart, the file is kept as it is
+			result.Status = UpgradeStatusKept
+			result.Message = "edited and the generated version is not known, the changes of the blueprint are not applied"
+			result.Diff = util.UnifiedDiff("a/"+file.Path, "b/"+file.Path, string(local), file.Content, 3)
+			return result, nil
+		}
+		merged, conflict := util.Merge3(base, string(local), file.Content, "local", fmt.Sprintf("blueprint %s", manifest.Blueprint))
+		content = merged
+		result.Status = UpgradeStatusMerged
+		if conflict {
+			result.Status = UpgradeStatusConflict
+		}
+	}
+	return result, writeDataToFile(generatedBlueprint, file.Path, &content)
+}
+
+// removeUpgradedFile removes a file that is not generated by the new version, if it was not edited
+func removeUpgradedFile(generatedBlueprint *GeneratedBlueprint, previous ManifestFile) (*UpgradeFile, error) {
+	filePath := generatedBlueprint.OutputPath(filepath.FromSlash(previous.Path))
+	local, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hashContent(string(local)) != previous.SHA256 {
+		return &UpgradeFile{Path: previous.Path, Status: UpgradeStatusKept, Message: "no longer generated, kept because it was edited"}, nil
+	}
+	if !generatedBlueprint.DryRun {
+		util.Verbose("[file] Removing file %s, it is no longer generated\n", filePath)
+		if err := generatedBlueprint.removeFile(filePath); err != nil {
+			return nil, err
+		}
+	}
+	return &UpgradeFile{Path: previous.Path, Status: UpgradeStatusRemoved}, nil
+}
@@ -0,0 +1,212 @@
+package blueprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const upgradeTestBlueprint = `
+apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  version: "%s"
+spec:
+  parameters:
+  - name: AppName
+    prompt: Application name?
+    type: Input
+  - name: Password
+    prompt: Password?
+    type: SecretInput
+  - name: Replicas
+    value: 1
+  files:
+  - path: app.yaml.tmpl
+%s`
+
+func upgradeTestBlueprintYaml(version string, files ...string) string {
+	fileList := ""
+	for _, file := range files {
+		fileList += fmt.Sprintf("  - path: %s\n", file)
+	}
+	return fmt.Sprintf(upgradeTestBlueprint, version, fileList)
+}
+
+func writeUpgradeTestFile(t *testing.T, dir string, name string, content string) {
+	require.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestUpgradeBlueprint(t *testing.T) {
+	repoDir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"app/blueprint.yaml": upgradeTestBlueprintYaml("1.0", "config.yaml", "notes.txt"),
+		"app/app.yaml.tmpl":  "name: {{.AppName}}\nreplicas: 1\nport: 80\nimage: app:1",
+		"app/config.yaml":    "level: info\n",
+		"app/notes.txt":      "notes\n",
+	})
+	defer cleanup()
+	blueprintContext, err := ConstructLocalBlueprintContext(repoDir)
+	require.Nil(t, err)
+	projectDir := filepath.Join(repoDir, "project")
+
+	// generate the first version
+	gb := &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs"}
+	_, _, err = InstantiateBlueprint(
+		BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{"AppName": "demo", "Password": "s3cret"}, StrictAnswers: true},
+		blueprintContext, gb, nil,
+	)
+	require.Nil(t, err)
+	require.Nil(t, gb.Finish())
+
+	t.Run("should write a manifest without secrets", func(t *testing.T) {
+		manifest, err := ReadBlueprintManifest(filepath.Join(projectDir, "xebialabs", manifestFile))
+		require.Nil(t, err)
+		assert.Equal(t, "app", manifest.Blueprint)
+		assert.Equal(t, "1.0", manifest.Version)
+		assert.Equal(t, map[string]string{"AppName": "demo"}, manifest.Answers)
+		assert.Equal(t, []ManifestFile{
+			{Path: "app.yaml", SHA256: hashContent("name: demo\nreplicas: 1\nport: 80\nimage: app:1")},
+			{Path: "config.yaml", SHA256: hashContent("level: info\n")},
+			{Path: "notes.txt", SHA256: hashContent("notes\n")},
+		}, manifest.Files)
+		assert.Equal(t, "level: info\n", GetFileContent(filepath.Join(projectDir, "xebialabs", manifestBaseDir, "config.yaml")))
+		assert.Equal(t, "secrets.xlvals", GetFileContent(filepath.Join(projectDir, "xebialabs", gitignoreFile)))
+	})
+
+	// edit the project and release a new version of the blueprint
+	writeUpgradeTestFile(t, projectDir, "app.yaml", "name: demo\nreplicas: 3\nport: 80\nimage: app:1")
+	writeUpgradeTestFile(t, projectDir, "config.yaml", "level: debug\n")
+	writeUpgradeTestFile(t, repoDir, "app/blueprint.yaml", upgradeTestBlueprintYaml("2.0", "config.yaml", "notes.txt", "extra.yaml"))
+	writeUpgradeTestFile(t, repoDir, "app/app.yaml.tmpl", "name: {{.AppName}}\nreplicas: 1\nport: 80\nimage: app:2")
+	writeUpgradeTestFile(t, repoDir, "app/config.yaml", "level: warn\n")
+	writeUpgradeTestFile(t, repoDir, "app/notes.txt", "release notes\n")
+	writeUpgradeTestFile(t, repoDir, "app/extra.yaml", "extra: true\n")
+	blueprintContext, err = ConstructLocalBlueprintContext(repoDir)
+	require.Nil(t, err)
+
+	manifest, err := ReadBlueprintManifest(filepath.Join(projectDir, "xebialabs", manifestFile))
+	require.Nil(t, err)
+
+	t.Run("should not write anything in dry-run mode", func(t *testing.T) {
+		dryRun := &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs", DryRun: true}
+		results, err := UpgradeBlueprint(blueprintContext, dryRun, manifest, "")
+		require.Nil(t, err)
+		assert.NotEmpty(t, results)
+		assert.Equal(t, "level: debug\n", GetFileContent(filepath.Join(projectDir, "config.yaml")))
+		_, err = os.Stat(filepath.Join(projectDir, "extra.yaml"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should merge the new version into the edited project", func(t *testing.T) {
+		upgraded := &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs"}
+		results, err := UpgradeBlueprint(blueprintContext, upgraded, manifest, "")
+		require.Nil(t, err)
+
+		statuses := make(map[string]string)
+		for _, result := range results {
+			statuses[result.Path] = result.Status
+		}
+		assert.Equal(t, map[string]string{
+			"xebialabs/values.xlvals":  UpgradeStatusUnchanged,
+			"xebialabs/secrets.xlvals": UpgradeStatusUnchanged,
+			"xebialabs/.gitignore":     UpgradeStatusUnchanged,
+			"app.yaml":                 UpgradeStatusMerged,
+			"config.yaml":              UpgradeStatusConflict,
+			"notes.txt":                UpgradeStatusUpdated,
+			"extra.yaml":               UpgradeStatusCreated,
+		}, statuses)
+
+		assert.Equal(t, "name: demo\nreplicas: 3\nport: 80\nimage: app:2", GetFileContent(filepath.Join(projectDir, "app.yaml")))
+		assert.Equal(t, "<<<<<<< local\nlevel: debug\n=======\nlevel: warn\n>>>>>>> blueprint app\n", GetFileContent(filepath.Join(projectDir, "config.yaml")))
+		assert.Equal(t, "release notes\n", GetFileContent(filepath.Join(projectDir, "notes.txt")))
+		assert.Equal(t, "extra: true\n", GetFileContent(filepath.Join(projectDir, "extra.yaml")))
+
+		// the manifest records the new version as generated
+		newManifest, err := ReadBlueprintManifest(filepath.Join(projectDir, "xebialabs", manifestFile))
+		require.Nil(t, err)
+		assert.Equal(t, "2.0", newManifest.Version)
+		assert.Equal(t, hashContent("level: warn\n"), newManifest.findFile("config.yaml").SHA256)
+		assert.Equal(t, "level: warn\n", GetFileContent(filepath.Join(projectDir, "xebialabs", manifestBaseDir, "config.yaml")))
+	})
+
+	t.Run("should remove files no longer generated unless they were edited", func(t *testing.T) {
+		writeUpgradeTestFile(t, repoDir, "app/blueprint.yaml", upgradeTestBlueprintYaml("3.0"))
+		writeUpgradeTestFile(t, projectDir, "config.yaml", "level: debug\n")
+		blueprintContext, err := ConstructLocalBlueprintContext(repoDir)
+		require.Nil(t, err)
+		manifest, err := ReadBlueprintManifest(filepath.Join(projectDir, "xebialabs", manifestFile))
+		require.Nil(t, err)
+
+		results, err := UpgradeBlueprint(blueprintContext, &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs"}, manifest, "")
+		require.Nil(t, err)
+
+		assert.Contains(t, results, UpgradeFile{Path: "notes.txt", Status: UpgradeStatusRemoved})
+		assert.Contains(t, results, UpgradeFile{Path: "extra.yaml", Status: UpgradeStatusRemoved})
+		assert.Contains(t, results, UpgradeFile{Path: "config.yaml", Status: UpgradeStatusKept, Message: "no longer generated, kept because it was edited"})
+		_, err = os.Stat(filepath.Join(projectDir, "notes.txt"))
+		assert.True(t, os.IsNotExist(err))
+		assert.Equal(t, "level: debug\n", GetFileContent(filepath.Join(projectDir, "config.yaml")))
+		_, err = os.Stat(filepath.Join(projectDir, "xebialabs", manifestBaseDir, "notes.txt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestUpgradeBlueprintWithoutMergeBase(t *testing.T) {
+	repoDir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"app/blueprint.yaml": upgradeTestBlueprintYaml("1.0"),
+		"app/app.yaml.tmpl":  "name: {{.AppName}}\nimage: app:1",
+	})
+	defer cleanup()
+	blueprintContext, err := ConstructLocalBlueprintContext(repoDir)
+	require.Nil(t, err)
+	projectDir := filepath.Join(repoDir, "project")
+
+	gb := &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs"}
+	_, _, err = InstantiateBlueprint(
+		BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{"AppName": "demo", "Password": "s3cret"}, StrictAnswers: true},
+		blueprintContext, gb, nil,
+	)
+	require.Nil(t, err)
+	require.Nil(t, gb.Finish())
+	// the copy of the generated files is lost, as in a project generated before it was kept
+	require.Nil(t, os.RemoveAll(filepath.Join(projectDir, "xebialabs", manifestBaseDir)))
+
+	writeUpgradeTestFile(t, projectDir, "app.yaml", "name: demo\nimage: app:1\nreplicas: 3")
+	writeUpgradeTestFile(t, repoDir, "app/app.yaml.tmpl", "name: {{.AppName}}\nimage: app:2")
+	manifest, err := ReadBlueprintManifest(filepath.Join(projectDir, "xebialabs", manifestFile))
+	require.Nil(t, err)
+
+	results, err := UpgradeBlueprint(blueprintContext, &GeneratedBlueprint{BaseDir: projectDir, OutputDir: "xebialabs"}, manifest, "")
+	require.Nil(t, err)
+	assert.Contains(t, results, UpgradeFile{
+		Path:    "app.yaml",
+		Status:  UpgradeStatusKept,
+		Message: "edited and the generated version is not known, the changes of the blueprint are not applied",
+		Diff:    "--- a/app.yaml\n+++ b/app.yaml\n@@ -1,3 +1,2 @@\n name: demo\n-image: app:1\n+image: app:2\n-replicas: 3\n",
+	})
+	assert.Equal(t, "name: demo\nimage: app:1\nreplicas: 3", GetFileContent(filepath.Join(projectDir, "app.yaml")))
+	// the upgrade keeps a copy of the new version for the next one
+	assert.Equal(t, "name: demo\nimage: app:2", GetFileContent(filepath.Join(projectDir, "xebialabs", manifestBaseDir, "app.yaml")))
+}
+
+func TestReadBlueprintManifest(t *testing.T) {
+	dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+		"invalid.yaml": "blueprint: app\nunknown: field\n",
+		"empty.yaml":   "version: 1.0\n",
+	})
+	defer cleanup()
+
+	_, err := ReadBlueprintManifest(filepath.Join(dir, "missing.yaml"))
+	assert.NotNil(t, err)
+	_, err = ReadBlueprintManifest(filepath.Join(dir, "invalid.yaml"))
+	assert.NotNil(t, err)
+	_, err = ReadBlueprintManifest(filepath.Join(dir, "empty.yaml"))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "blueprint is missing")
+}
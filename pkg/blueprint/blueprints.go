@@ -79,10 +79,31 @@ type BlueprintParams struct {
 	PrintSummaryTable    bool
 	ExistingPreparedData *PreparedData
 	OverrideDefaults     map[string]string
-	AnswersMap           map[string]string
+	// AnswersMap takes precedence over the answers file and the environment, answers missing from it are still read
+	// from the answers file when one is given
+	AnswersMap map[string]string
+	// AnswerFiles gives answers as files, by parameter name, with the same precedence as AnswersMap. File parameters
+	// get the path of the file and other parameters its content.
+	AnswerFiles map[string]string
+	// AnswersEnvPrefix enables answers from environment variables named with the prefix followed by the parameter name
+	AnswersEnvPrefix string
+	OnConflict       string
+	// SkipFinalPrompt generates the blueprint without asking for a confirmation
+	SkipFinalPrompt bool
+	// SkipUserInput leaves the parameters without an answer empty instead of asking them
+	SkipUserInput bool
+	// KeepBackups keeps the backups of the replaced files once the output is committed, so that the caller can still
+	// roll back with Cleanup, and must call Finish when it succeeded
+	KeepBackups bool
 }
 
-// InstantiateBlueprint is entry point for the cli command
+// hasAnswers returns true when answers are given in any way, instead of asking all questions
+func (params BlueprintParams) hasAnswers() bool {
+	return params.AnswersFile != "" || params.AnswersMap != nil || params.AnswerFiles != nil || params.AnswersEnvPrefix != ""
+}
+
+// InstantiateBlueprint is entry point for the cli command. The output files are committed when it succeeds, call Finish
+// on the generated blueprint once the command has succeeded, or Cleanup to restore the prior state of the project.
 func InstantiateBlueprint(
 	params BlueprintParams,
 	blueprintContext *BlueprintContext,
@@ -93,6 +114,12 @@ func InstantiateBlueprint(
 	var err error
 	var blueprints map[string]*models.BlueprintRemote
 
+	// a blueprint of another repository can be used with a repo-name:blueprint/path reference
+	blueprintContext, params.TemplatePath, err = blueprintContext.ResolveBlueprintPath(params.TemplatePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// initialize repository client
 	util.Verbose("[cmd] Reading blueprints from provider: %s\n", (*blueprintContext.ActiveRepo).GetProvider())
 	blueprints, err = blueprintContext.initCurrentRepoClient()
@@ -108,10 +135,11 @@ func InstantiateBlueprint(
 		}
 	}
 
-	preparedData, blueprintDoc, err := prepareMergedTemplateData(blueprintContext, blueprints, params, overrideFns, surveyOpts...)
+	preparedData, blueprintDoc, composition, err := prepareMergedTemplateData(blueprintContext, blueprints, params, overrideFns, surveyOpts...)
 	if err != nil {
 		return nil, nil, err
 	}
+	generatedBlueprint.Composition = composition
 	util.Verbose("[dataPrep] Prepared data: %#v\n", preparedData)
 
 	// if this is from UP command, ask confirmation for xl-up
@@ -128,7 +156,25 @@ func InstantiateBlueprint(
 		}
 	}
 
+	generatedBlueprint.conflictPolicy, err = getConflictPolicy(params.OnConflict, params.StrictAnswers)
+	if err != nil {
+		return nil, nil, err
+	}
+	generatedBlueprint.surveyOpts = surveyOpts
+
+	// render all the templates first, so that nothing is written when one of them is broken
+	renderedFiles, err := renderTemplateFiles(blueprintContext, blueprintDoc, preparedData, composition, params.TemplatePath, overrideFns)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	createXebiaLabsFolder := !blueprintDoc.Metadata.SuppressXebiaLabsFolder
+	if blueprintDoc.Metadata.XebiaLabsFolder != "" {
+		generatedBlueprint.OutputDir = blueprintDoc.Metadata.XebiaLabsFolder
+	}
+
+	// the manifest is written in the xebialabs folder with the values and secrets
+	writeManifestFile := createXebiaLabsFolder || len(preparedData.Values) != 0 || len(preparedData.Secrets) != 0
 
 	// save prepared data to values & secrets files
 	if createXebiaLabsFolder || len(preparedData.Values) != 0 {
@@ -138,29 +184,100 @@ func InstantiateBlueprint(
 		}
 	}
 
+	var gitignoreEntries []string
 	if createXebiaLabsFolder || len(preparedData.Secrets) != 0 {
 		err = writeConfigToFile(secretsFileHeader, preparedData.Secrets, generatedBlueprint, filepath.Join(generatedBlueprint.OutputDir, secretsFile))
 		if err != nil {
 			return nil, nil, err
 		}
-		// generate .gitignore file
-		gitignoreData := secretsFile
-		err = writeDataToFile(generatedBlueprint, filepath.Join(generatedBlueprint.OutputDir, gitignoreFile), &gitignoreData)
+		gitignoreEntries = append(gitignoreEntries, secretsFile)
+	}
+
+	if len(gitignoreEntries) != 0 {
+		// generate .gitignore file, entries of an existing file are kept
+		gitignorePath := filepath.Join(generatedBlueprint.OutputDir, gitignoreFile)
+		gitignoreData, err := generatedBlueprint.mergeGitignore(gitignorePath, strings.Join(gitignoreEntries, "\n"))
 		if err != nil {
 			return nil, nil, err
 		}
+		err = writeOutputFile(generatedBlueprint, gitignorePath, &gitignoreData)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// write the template files once all of them are rendered
+	for _, file := range renderedFiles {
+		if file.skipReason != "" {
+			generatedBlueprint.recordSkippedFile(file.path, file.skipReason)
+			continue
+		}
+		data := file.data
+		if err := writeDataToFile(generatedBlueprint, file.path, &data); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, inclusion := range composition {
+		if !inclusion.Included {
+			for _, config := range inclusion.templateConfigs {
+				generatedBlueprint.recordSkippedFile(getOutputFileName(config), inclusion.skipReason())
+			}
+		}
+	}
+	// record how the project is generated so that it can be upgraded later on
+	generatedBlueprint.Manifest = newBlueprintManifest(params.TemplatePath, blueprintContext, blueprintDoc, preparedData, generatedBlueprint)
+	if !generatedBlueprint.DryRun && writeManifestFile {
+		if err := writeManifest(generatedBlueprint, generatedBlueprint.Manifest, generatedBlueprint.Files); err != nil {
+			return nil, nil, err
+		}
 	}
+	if err := generatedBlueprint.Commit(); err != nil {
+		return nil, nil, err
+	}
+	if !params.KeepBackups {
+		if err := generatedBlueprint.Finish(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !generatedBlueprint.DryRun {
+		util.Info("Please refer to file '%s' for the default secrets\n", generatedBlueprint.OutputPath(filepath.Join(generatedBlueprint.OutputDir, secretsFile)))
+	}
+	if blueprintDoc.Metadata.Instructions != "" {
+		util.Info("\n\n%s\n\n", color.GreenString(blueprintDoc.Metadata.Instructions))
+	}
+	return preparedData, blueprintDoc, nil
+}
 
-	// execute each template file found
+// renderedFile is a blueprint file ready to be written, or a file skipped because of its writeIf condition
+type renderedFile struct {
+	path       string
+	data       string
+	skipReason string
+}
+
+// renderTemplateFiles renders the files of a blueprint without writing them. Template errors are collected so that
+// all broken templates are reported at once.
+func renderTemplateFiles(
+	blueprintContext *BlueprintContext,
+	blueprintDoc *BlueprintConfig,
+	preparedData *PreparedData,
+	composition []BlueprintInclusion,
+	templatePath string,
+	overrideFns ExpressionOverrideFn,
+) ([]renderedFile, error) {
+	var renderedFiles []renderedFile
+	var templateErrors TemplateErrors
 	for _, config := range blueprintDoc.TemplateConfigs {
+		writeIf := formatVarField(config.DependsOn)
 		config.ProcessExpression(preparedData.TemplateData, overrideFns)
 		skipFile, err := shouldSkipFile(config, preparedData.TemplateData)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		if skipFile {
 			util.Verbose("[file] skipping file [%s] since it has writeIf value set or is skipped by composed blueprint\n", config.Path)
+			renderedFiles = append(renderedFiles, renderedFile{path: getOutputFileName(config), skipReason: fmt.Sprintf("writeIf condition [%s] is not met", writeIf)})
 			continue
 		}
 
@@ -168,7 +285,7 @@ func InstantiateBlueprint(
 		util.Verbose("[file] Fetching template file %s from %s\n", config.Path, config.FullPath)
 		templateContent, err := blueprintContext.fetchFileContents(config.FullPath, strings.HasSuffix(config.Path, templateExtension))
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		templateString := string(*templateContent)
 		finalFileName := config.Path
@@ -182,20 +299,20 @@ func InstantiateBlueprint(
 			util.Verbose("[file] Processing template file %s\n", config.FullPath)
 
 			// read & process the template
-			tmpl := template.Must(template.New(config.Path).Funcs(getFuncMaps()).Parse(templateString))
-			processedTmpl := &strings.Builder{}
-			err = tmpl.Execute(processedTmpl, preparedData.TemplateData)
+			tmpl, err := template.New(config.Path).Funcs(getFuncMaps()).Parse(templateString)
 			if err != nil {
-				return nil, nil, err
+				blueprintName := findTemplateBlueprint(composition, config, templatePath)
+				templateErrors = append(templateErrors, newTemplateError(blueprintName, config, templateString, templatePhaseParse, err))
+				continue
 			}
-
-			// write the processed template to a file
-			finalTmpl := strings.TrimSpace(processedTmpl.String())
-
-			err = writeDataToFile(generatedBlueprint, strings.Replace(finalFileName, templateExtension, "", 1), &finalTmpl)
+			processedTmpl := &strings.Builder{}
+			err = tmpl.Execute(processedTmpl, preparedData.TemplateData)
 			if err != nil {
-				return nil, nil, err
+				blueprintName := findTemplateBlueprint(composition, config, templatePath)
+				templateErrors = append(templateErrors, newTemplateError(blueprintName, config, templateString, templatePhaseExecute, err))
+				continue
 			}
+			renderedFiles = append(renderedFiles, renderedFile{path: getOutputFileName(config), data: strings.TrimSpace(processedTmpl.String())})
 		} else {
 			if funk.ContainsString(ignoredPaths, filepath.Base(filepath.Dir(config.FullPath))) {
 				// skip files under ignored directories
@@ -203,18 +320,14 @@ func InstantiateBlueprint(
 			} else {
 				// handle non-template files - copy as-it-is
 				util.Verbose("[file] Copying file %s\n", config.FullPath)
-				err = writeDataToFile(generatedBlueprint, finalFileName, &templateString)
-				if err != nil {
-					return nil, nil, err
-				}
+				renderedFiles = append(renderedFiles, renderedFile{path: finalFileName, data: templateString})
 			}
 		}
 	}
-	util.Info("Please refer to file 'xebialabs/secrets.xlvals' for the default secrets\n")
-	if blueprintDoc.Metadata.Instructions != "" {
-		util.Info("\n\n%s\n\n", color.GreenString(blueprintDoc.Metadata.Instructions))
+	if len(templateErrors) != 0 {
+		return nil, templateErrors
 	}
-	return preparedData, blueprintDoc, nil
+	return renderedFiles, nil
 }
 
 func prepareMergedTemplateData(
@@ -223,11 +336,11 @@ func prepareMergedTemplateData(
 	params BlueprintParams,
 	overrideFns ExpressionOverrideFn,
 	surveyOpts ...survey.AskOpt,
-) (*PreparedData, *BlueprintConfig, error) {
+) (*PreparedData, *BlueprintConfig, []BlueprintInclusion, error) {
 	// get blueprint definition
 	blueprintDocs, masterBlueprintDoc, err := getBlueprintConfig(blueprintContext, blueprints, params.TemplatePath, []VarField{VarField{}}, "")
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	mergedData := NewPreparedData()
@@ -246,6 +359,7 @@ func prepareMergedTemplateData(
 	}
 	// A map holding skipped blueprint names
 	var skippedBlueprints []string
+	composition := make([]BlueprintInclusion, 0, len(blueprintDocs))
 	for _, blueprintDoc := range blueprintDocs {
 		var ok = true
 		// skip child templates when parents are skipped
@@ -259,14 +373,14 @@ func prepareMergedTemplateData(
 			// Evaluate dependsOn
 			ok, err = evaluateAndSkipIfDependsOnIsFalse(blueprintDoc.DependsOn, mergedData, overrideFns)
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 		}
 		if ok {
 			// ask for user input
 			preparedData, err := blueprintDoc.BlueprintConfig.prepareTemplateData(params, mergedData, overrideFns, surveyOpts...)
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 
 			// merge
@@ -281,31 +395,33 @@ func prepareMergedTemplateData(
 		} else {
 			skippedBlueprints = append(skippedBlueprints, blueprintDoc.Name)
 		}
+		composition = append(composition, newBlueprintInclusion(blueprintDoc, ok))
 	}
 
 	// Print summary table
 	if params.PrintSummaryTable {
 		// use util.Print so that this is not skipped in quiet mode
-		if params.UseDefaultsAsValue && params.AnswersFile == "" && params.AnswersMap == nil {
+		if params.UseDefaultsAsValue && !params.hasAnswers() {
 			util.Print("Using default values:\n")
 		}
 
 		util.Print(util.DataMapTable(&mergedData.SummaryData, util.TableAlignLeft, 30, 50, "\t", 1, params.FromUpCommand))
 	}
 
-	if !SkipFinalPrompt {
+	// skip final prompt if in strict answers mode
+	if !SkipFinalPrompt && !params.SkipFinalPrompt && !(params.StrictAnswers && params.hasAnswers()) {
 		// Final prompt from user to start generation process
 		toContinue := false
 		err := survey.AskOne(&survey.Confirm{Message: models.BlueprintFinalPrompt, Default: true}, &toContinue, nil, surveyOpts...)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		if !toContinue {
-			return nil, nil, fmt.Errorf("blueprint generation cancelled")
+			return nil, nil, nil, fmt.Errorf("blueprint generation cancelled")
 		}
 	}
 
-	return mergedData, mergedBlueprintDoc, nil
+	return mergedData, mergedBlueprintDoc, composition, nil
 }
 
 func evaluateAndSkipIfDependsOnIsFalse(dependsOn []VarField, mergedData *PreparedData, overrideFns ExpressionOverrideFn) (bool, error) {
@@ -442,9 +558,40 @@ func findTemplateConfig(configs []TemplateConfig, path string) int {
 }
 
 // --utility functions
+
+// getOutputFileName returns the name of the file generated for a template config
+func getOutputFileName(config TemplateConfig) string {
+	fileName := config.Path
+	if config.RenameTo.Value != "" {
+		fileName = config.RenameTo.Value
+	}
+	if strings.HasSuffix(config.Path, templateExtension) {
+		fileName = strings.Replace(fileName, templateExtension, "", 1)
+	}
+	return fileName
+}
+
 func writeDataToFile(generatedBlueprint *GeneratedBlueprint, outputFileName string, data *string) error {
+	outputFileName, write, err := generatedBlueprint.resolveConflict(outputFileName, *data)
+	if err != nil {
+		return err
+	}
+	if !write {
+		generatedBlueprint.recordKeptFile(outputFileName, *data)
+		return nil
+	}
+	return writeOutputFile(generatedBlueprint, outputFileName, data)
+}
+
+// writeOutputFile writes an output file, replacing the existing file if any
+func writeOutputFile(generatedBlueprint *GeneratedBlueprint, outputFileName string, data *string) error {
+	generatedBlueprint.recordFile(outputFileName, *data)
+	if generatedBlueprint.DryRun {
+		util.Verbose("[file] Dry run, not writing blueprint output file %s\n", outputFileName)
+		return nil
+	}
 	util.Verbose("[file] Creating blueprint output file %s\n", outputFileName)
-	file, err := generatedBlueprint.GetOutputFile(outputFileName)
+	file, err := generatedBlueprint.createStagedFile(generatedBlueprint.OutputPath(outputFileName))
 	if err != nil {
 		return err
 	}
@@ -461,7 +608,7 @@ func writeDataToFile(generatedBlueprint *GeneratedBlueprint, outputFileName stri
 	if err != nil {
 		return err
 	}
-	util.Info("[file] Blueprint output file '%s' generated successfully\n", outputFileName)
+	util.Info("[file] Blueprint output file '%s' generated successfully\n", generatedBlueprint.OutputPath(outputFileName))
 	return nil
 }
 
@@ -482,20 +629,11 @@ func writeConfigToFile(header string, config map[string]interface{}, generatedBl
 	}
 
 	// write properties to file
-	f, err := generatedBlueprint.GetOutputFile(filename)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	bytesWrittenHeader, err := f.Write([]byte(header + "\n"))
-	if err != nil {
+	content := &strings.Builder{}
+	content.WriteString(header + "\n")
+	if _, err := props.Write(content, properties.UTF8); err != nil {
 		return err
 	}
-	bytesWrittenConfig, err := props.Write(f, properties.UTF8)
-	if err != nil {
-		return err
-	}
-	util.Verbose("\tWrote %d bytes \n", bytesWrittenHeader+bytesWrittenConfig)
-	util.Info("[file] Blueprint output file '%s' generated successfully\n", filename)
-	return nil
+	data := content.String()
+	return writeDataToFile(generatedBlueprint, filename, &data)
 }
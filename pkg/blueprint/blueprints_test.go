@@ -44,6 +44,18 @@ func GetTestTemplateDir(blueprint string) string {
 	return strings.Replace(pwd, path.Join("pkg", "blueprint"), path.Join("templates", "test", blueprint), -1)
 }
 
+// writeTestBlueprintDir writes the files to a new temporary directory, for tests that change the blueprint files
+func writeTestBlueprintDir(t *testing.T, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "blueprinttest")
+	require.Nil(t, err)
+	for name, content := range files {
+		filePath := filepath.Join(dir, name)
+		require.Nil(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.Nil(t, ioutil.WriteFile(filePath, []byte(content), 0644))
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
 func TestWriteDataToFile(t *testing.T) {
 	t.Run("should write template data to output file", func(t *testing.T) {
 		gb := new(GeneratedBlueprint)
@@ -52,6 +64,7 @@ func TestWriteDataToFile(t *testing.T) {
 		filePath := "test.yml"
 		err := writeDataToFile(gb, filePath, &data)
 		require.Nil(t, err)
+		require.Nil(t, gb.Commit())
 		assert.FileExists(t, filePath)
 		assert.Equal(t, GetFileContent(filePath), data)
 	})
@@ -62,6 +75,7 @@ func TestWriteDataToFile(t *testing.T) {
 		filePath := path.Join("test", "test.yml")
 		err := writeDataToFile(gb, filePath, &data)
 		require.Nil(t, err)
+		require.Nil(t, gb.Commit())
 		assert.FileExists(t, filePath)
 		assert.Equal(t, GetFileContent(filePath), data)
 	})
@@ -78,6 +92,7 @@ func TestWriteConfigToFile(t *testing.T) {
 		err := writeConfigToFile("#comment", config, gb, filePath)
 		defer gb.Cleanup()
 		require.Nil(t, err)
+		require.Nil(t, gb.Commit())
 		assert.FileExists(t, filePath)
 		assert.Equal(t, "#comment\na = true\nd = 1\nz = test", strings.TrimSpace(GetFileContent(filePath)))
 	})
@@ -91,6 +106,7 @@ func TestWriteConfigToFile(t *testing.T) {
 		filePath := path.Join("test", "test.xlvals")
 		err := writeConfigToFile("#comment", config, gb, filePath)
 		require.Nil(t, err)
+		require.Nil(t, gb.Commit())
 		assert.FileExists(t, filePath)
 		assert.Equal(t, "#comment\na = true\nd = 1\nz = test", strings.TrimSpace(GetFileContent(filePath)))
 	})
@@ -215,6 +231,155 @@ func TestInstantiateBlueprint(t *testing.T) {
 		}
 	})
 
+	t.Run("should create output files in the base directory", func(t *testing.T) {
+		baseDir, err := ioutil.TempDir("", "blueprintoutput")
+		require.Nil(t, err)
+		defer os.RemoveAll(baseDir)
+		outputDir := filepath.Join(baseDir, "projects", "app")
+
+		gb := &GeneratedBlueprint{BaseDir: outputDir, OutputDir: "xebialabs"}
+		_, _, err = InstantiateBlueprint(
+			BlueprintParams{
+				TemplatePath:  "valid-no-prompt",
+				AnswersMap:    map[string]string{},
+				StrictAnswers: true,
+			},
+			getLocalTestBlueprintContext(t),
+			gb, nil,
+		)
+		require.Nil(t, err)
+
+		assert.FileExists(t, filepath.Join(outputDir, "xld-environment.yml"))
+		assert.FileExists(t, filepath.Join(outputDir, "xebialabs", valuesFile))
+		assert.FileExists(t, filepath.Join(outputDir, "xebialabs", gitignoreFile))
+		_, err = os.Stat("xld-environment.yml")
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat("xebialabs")
+		assert.True(t, os.IsNotExist(err))
+
+		// created directories are removed as well
+		require.Nil(t, gb.Cleanup())
+		_, err = os.Stat(filepath.Join(baseDir, "projects"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should write values and secrets to the xebialabs folder of the blueprint", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"app/blueprint.yaml": `
+apiVersion: xl/v2
+kind: Blueprint
+metadata:
+  xebialabsFolder: config/xl
+spec:
+  parameters:
+  - name: AppName
+    value: my-app
+    saveInXlvals: true
+  files:
+  - path: app.yaml.tmpl
+`,
+			"app/app.yaml.tmpl": "name: {{.AppName}}",
+		})
+		defer cleanup()
+		blueprintContext, err := ConstructLocalBlueprintContext(dir)
+		require.Nil(t, err)
+
+		gb := &GeneratedBlueprint{BaseDir: filepath.Join(dir, "out"), OutputDir: "xebialabs"}
+		_, _, err = InstantiateBlueprint(BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{}, StrictAnswers: true}, blueprintContext, gb, nil)
+		require.Nil(t, err)
+
+		assert.Equal(t, "config/xl", gb.OutputDir)
+		assert.Equal(t, "name: my-app", GetFileContent(filepath.Join(dir, "out", "app.yaml")))
+		assert.Contains(t, GetFileContent(filepath.Join(dir, "out", "config", "xl", valuesFile)), "AppName = my-app")
+		assert.Equal(t, secretsFile, GetFileContent(filepath.Join(dir, "out", "config", "xl", gitignoreFile)))
+		_, err = os.Stat(filepath.Join(dir, "out", "xebialabs"))
+		assert.True(t, os.IsNotExist(err))
+
+		require.Nil(t, gb.Cleanup())
+		_, err = os.Stat(filepath.Join(dir, "out"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should not leave a staging directory after the generation", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"app/blueprint.yaml":                   "apiVersion: xl/v2\nkind: Blueprint\nspec:\n  files:\n  - path: app.yaml\n",
+			"app/app.yaml":                         "name: app",
+			"out/app.yaml":                         "name: old",
+			"out/" + stagingDirPrefix + "1/0.orig": "left by a crash",
+		})
+		defer cleanup()
+		blueprintContext, err := ConstructLocalBlueprintContext(dir)
+		require.Nil(t, err)
+		params := BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{}, StrictAnswers: true, OnConflict: ConflictPolicyOverwrite}
+
+		gb := &GeneratedBlueprint{BaseDir: filepath.Join(dir, "out"), OutputDir: "xebialabs"}
+		_, _, err = InstantiateBlueprint(params, blueprintContext, gb, nil)
+		require.Nil(t, err)
+		assert.Equal(t, "name: app", GetFileContent(filepath.Join(dir, "out", "app.yaml")))
+		assert.Empty(t, stagingDirs(t, filepath.Join(dir, "out")))
+
+		// the backups are kept when asked, until the caller finishes the generation
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "out", "app.yaml"), []byte("name: edited"), 0644))
+		params.KeepBackups = true
+		gb = &GeneratedBlueprint{BaseDir: filepath.Join(dir, "out"), OutputDir: "xebialabs"}
+		_, _, err = InstantiateBlueprint(params, blueprintContext, gb, nil)
+		require.Nil(t, err)
+		assert.Len(t, stagingDirs(t, filepath.Join(dir, "out")), 1)
+		require.Nil(t, gb.Finish())
+		assert.Empty(t, stagingDirs(t, filepath.Join(dir, "out")))
+	})
+
+	t.Run("should error on xebialabs folder outside of the output directory", func(t *testing.T) {
+		dir, cleanup := writeTestBlueprintDir(t, map[string]string{
+			"app/blueprint.yaml": "apiVersion: xl/v2\nkind: Blueprint\nmetadata:\n  xebialabsFolder: ../xebialabs\nspec:\n  parameters: []",
+		})
+		defer cleanup()
+		blueprintContext, err := ConstructLocalBlueprintContext(dir)
+		require.Nil(t, err)
+
+		gb := &GeneratedBlueprint{BaseDir: filepath.Join(dir, "out"), OutputDir: "xebialabs"}
+		defer gb.Cleanup()
+		_, _, err = InstantiateBlueprint(BlueprintParams{TemplatePath: "app", AnswersMap: map[string]string{}, StrictAnswers: true}, blueprintContext, gb, nil)
+		require.NotNil(t, err)
+		assert.Equal(t, "xebialabsFolder must be a path inside the output directory", err.Error())
+	})
+
+	t.Run("should only record output files in dry-run mode", func(t *testing.T) {
+		require.Nil(t, ioutil.WriteFile("xlr-pipeline.yml", []byte("existing"), 0644))
+		defer os.Remove("xlr-pipeline.yml")
+
+		gb := &GeneratedBlueprint{OutputDir: "xebialabs", DryRun: true}
+		defer gb.Cleanup()
+		_, _, err := InstantiateBlueprint(
+			BlueprintParams{
+				TemplatePath:  "valid-no-prompt",
+				AnswersMap:    map[string]string{},
+				StrictAnswers: true,
+			},
+			getLocalTestBlueprintContext(t),
+			gb, nil,
+		)
+		require.Nil(t, err)
+
+		assert.Empty(t, gb.GeneratedFiles)
+		_, err = os.Stat(gb.OutputDir)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat("xld-environment.yml")
+		assert.True(t, os.IsNotExist(err))
+		assert.Equal(t, "existing", GetFileContent("xlr-pipeline.yml"))
+
+		records := make(map[string]FileRecord)
+		for _, record := range gb.Files {
+			records[record.Path] = record
+		}
+		assert.Equal(t, FileStatusCreated, records["xld-environment.yml"].Status)
+		assert.Equal(t, len(records["xld-environment.yml"].Content), records["xld-environment.yml"].Size)
+		assert.Contains(t, records["xld-environment.yml"].Content, "kind: Environments")
+		assert.Equal(t, FileStatusCreated, records[path.Join(gb.OutputDir, valuesFile)].Status)
+		assert.Equal(t, FileStatusCreated, records["xlr-pipeline-2.yml"].Status)
+		assert.Equal(t, FileStatusSkipped, records["xlr-pipeline.yml"].Status)
+	})
+
 	t.Run("should create output files for valid test template with answers file", func(t *testing.T) {
 		gb := &GeneratedBlueprint{OutputDir: "xebialabs"}
 		defer gb.Cleanup()
@@ -2103,7 +2268,7 @@ func Test_prepareMergedTemplateData(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, err := prepareMergedTemplateData(
+			got, got1, _, err := prepareMergedTemplateData(
 				tt.args.blueprintContext,
 				tt.args.blueprints,
 				tt.args.params,
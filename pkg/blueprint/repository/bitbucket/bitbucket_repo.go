@@ -84,10 +84,8 @@ func (repo *BitbucketBlueprintRepository) GetInfo() string {
 	)
 }
 
-func (repo *BitbucketBlueprintRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
-	blueprints := make(map[string]*models.BlueprintRemote)
-	var blueprintDirs []string
-
+// GetRevision returns the hash of the latest commit of the branch
+func (repo *BitbucketBlueprintRepository) GetRevision() (string, error) {
 	co := &bitbucket.CommitsOptions{
 		Owner:    repo.Owner,
 		RepoSlug: repo.RepoName,
@@ -96,10 +94,19 @@ func (repo *BitbucketBlueprintRepository) ListBlueprintsFromRepo() (map[string]*
 
 	branch, err := repo.Client.Commits.GetCommit(co)
 	if err != nil {
-		return nil, nil, err
+		return "", err
 	}
+	return branch.(map[string]interface{})["hash"].(string), nil
+}
 
-	sha := branch.(map[string]interface{})["hash"].(string)
+func (repo *BitbucketBlueprintRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
+	blueprints := make(map[string]*models.BlueprintRemote)
+	var blueprintDirs []string
+
+	sha, err := repo.GetRevision()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	ro := &bitbucket.RepositoryFilesOptions{
 		Owner:    repo.Owner,
@@ -104,15 +104,23 @@ func (repo *BitbucketServerBlueprintRepository) GetInfo() string {
 	)
 }
 
+// GetRevision returns the ID of the latest commit of the branch
+func (repo *BitbucketServerBlueprintRepository) GetRevision() (string, error) {
+	branch, err := repo.Client.Repository.GetCommit(repo.ProjectKey, repo.RepoName, repo.Branch)
+	if err != nil {
+		return "", err
+	}
+	return branch["id"].(string), nil
+}
+
 func (repo *BitbucketServerBlueprintRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
 	blueprints := make(map[string]*models.BlueprintRemote)
 	var blueprintDirs []string
 
-	branch, err := repo.Client.Repository.GetCommit(repo.ProjectKey, repo.RepoName, repo.Branch)
+	sha, err := repo.GetRevision()
 	if err != nil {
 		return nil, nil, err
 	}
-	sha := branch["id"].(string)
 
 	repositoryFiles, err := repo.Client.Repository.ListFiles(repo.ProjectKey, repo.RepoName, sha)
 	if err != nil {
@@ -155,11 +163,10 @@ func (repo *BitbucketServerBlueprintRepository) ListBlueprintsFromRepo() (map[st
 }
 
 func (repo *BitbucketServerBlueprintRepository) GetFileContents(filePath string) (*[]byte, error) {
-	branch, err := repo.Client.Repository.GetCommit(repo.ProjectKey, repo.RepoName, repo.Branch)
+	sha, err := repo.GetRevision()
 	if err != nil {
 		return nil, err
 	}
-	sha := branch["id"].(string)
 
 	fileBlob, err := repo.Client.Repository.GetFileContents(repo.ProjectKey, repo.RepoName, filePath, sha)
 	if err != nil {
@@ -21,6 +21,12 @@ type BlueprintRepository interface {
 	GetFileContents(filePath string) (*[]byte, error)
 }
 
+// RevisionedRepository is implemented by remote repositories that can tell the revision of their content, like the
+// SHA of the latest commit of a branch, so that their content can be cached
+type RevisionedRepository interface {
+	GetRevision() (string, error)
+}
+
 // utility functions
 func GenerateBlueprintFileDefinition(blueprints map[string]*models.BlueprintRemote, blueprintPath string, filename string, path string, parsedUrl *url.URL) models.RemoteFile {
 	// Initialize map item if needed
@@ -0,0 +1,394 @@
+package cache
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xebialabs/blueprint-cli/pkg/blueprint/repository"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+	"github.com/xebialabs/blueprint-cli/pkg/util"
+	"github.com/xebialabs/yaml"
+)
+
+const (
+	repositoryFileName = "repository.yaml"
+	listingFileName    = "blueprints.yaml"
+	filesDirName       = "files"
+)
+
+var regExUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Cached Blueprint Repository implementation
+// wraps a remote repository and keeps its blueprint list and the files fetched from it on disk, keyed on the revision
+// of the repository. In offline mode the wrapped repository is never called and only the cached content is served.
+type CachedBlueprintRepository struct {
+	Repo    repository.BlueprintRepository
+	Dir     string
+	Offline bool
+
+	mutex    sync.Mutex
+	revision string
+}
+
+// CachedRepositoryInfo describes the cached content of a repository
+type CachedRepositoryInfo struct {
+	Name     string `yaml:"name"`
+	Provider string `yaml:"provider"`
+	Revision string `yaml:"revision"`
+	Updated  int64  `yaml:"updated"`
+	Dir      string `yaml:"-"`
+	Files    int    `yaml:"-"`
+	Size     int64  `yaml:"-"`
+}
+
+type cachedListing struct {
+	BlueprintDirs []string          `yaml:"blueprintDirs"`
+	Blueprints    []cachedBlueprint `yaml:"blueprints"`
+}
+
+type cachedBlueprint struct {
+	Name           string       `yaml:"name"`
+	Path           string       `yaml:"path"`
+	DefinitionFile cachedFile   `yaml:"definitionFile"`
+	Files          []cachedFile `yaml:"files"`
+}
+
+type cachedFile struct {
+	Filename string `yaml:"filename"`
+	Path     string `yaml:"path"`
+	Url      string `yaml:"url,omitempty"`
+}
+
+func NewCachedBlueprintRepository(repo repository.BlueprintRepository, cacheDir string, offline bool) *CachedBlueprintRepository {
+	return &CachedBlueprintRepository{
+		Repo:    repo,
+		Dir:     filepath.Join(cacheDir, GetRepositoryCacheKey(repo)),
+		Offline: offline,
+	}
+}
+
+// GetRepositoryCacheKey returns the name of the cache directory of a repository, it changes when the repository
+// definition changes
+func GetRepositoryCacheKey(repo repository.BlueprintRepository) string {
+	hash := md5.Sum([]byte(repo.GetProvider() + "\n" + repo.GetInfo()))
+	return fmt.Sprintf("%s-%x", strings.Trim(regExUnsafeChars.ReplaceAllString(repo.GetName(), "_"), "_"), hash[:4])
+}
+
+func (repo *CachedBlueprintRepository) Initialize() error {
+	if repo.Offline {
+		return nil
+	}
+	return repo.Repo.Initialize()
+}
+
+func (repo *CachedBlueprintRepository) GetName() string {
+	return repo.Repo.GetName()
+}
+
+func (repo *CachedBlueprintRepository) GetProvider() string {
+	return repo.Repo.GetProvider()
+}
+
+func (repo *CachedBlueprintRepository) GetInfo() string {
+	info := repo.Repo.GetInfo()
+	if repo.Offline {
+		info += "\n  Offline: true"
+	}
+	return info
+}
+
+func (repo *CachedBlueprintRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
+	revision, err := repo.getRevision()
+	if err != nil {
+		return nil, nil, err
+	}
+	listingPath := filepath.Join(repo.revisionDir(revision), listingFileName)
+	if blueprints, blueprintDirs, err := readListing(listingPath); err == nil {
+		util.Verbose("[cache] Using cached blueprint list of repository %s at revision %s\n", repo.GetName(), revision)
+		return blueprints, blueprintDirs, nil
+	}
+	if repo.Offline {
+		return nil, nil, fmt.Errorf("blueprint list of repository %s is not in the offline cache", repo.GetName())
+	}
+
+	blueprints, blueprintDirs, err := repo.Repo.ListBlueprintsFromRepo()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeListing(listingPath, blueprints, blueprintDirs); err != nil {
+		util.Verbose("[cache] Cannot cache blueprint list of repository %s: %s\n", repo.GetName(), err)
+	}
+	return blueprints, blueprintDirs, nil
+}
+
+func (repo *CachedBlueprintRepository) GetFileContents(filePath string) (*[]byte, error) {
+	revision, err := repo.getRevision()
+	if err != nil {
+		return nil, err
+	}
+	cleanPath := path.Clean(strings.TrimPrefix(filePath, "/"))
+	if cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		if repo.Offline {
+			return nil, fmt.Errorf("file %s of repository %s is not in the offline cache", filePath, repo.GetName())
+		}
+		return repo.Repo.GetFileContents(filePath)
+	}
+
+	cachePath := filepath.Join(repo.revisionDir(revision), filesDirName, filepath.FromSlash(cleanPath))
+	if content, err := ioutil.ReadFile(cachePath); err == nil {
+		util.Verbose("[cache] Using cached file %s of repository %s\n", filePath, repo.GetName())
+		return &content, nil
+	}
+	if repo.Offline {
+		return nil, fmt.Errorf("file %s of repository %s is not in the offline cache", filePath, repo.GetName())
+	}
+
+	content, err := repo.Repo.GetFileContents(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(cachePath, *content); err != nil {
+		util.Verbose("[cache] Cannot cache file %s of repository %s: %s\n", filePath, repo.GetName(), err)
+	}
+	return content, nil
+}
+
+// getRevision returns the current revision of the repository, or the cached revision in offline mode.
+// When the revision changed, the content cached for older revisions is removed.
+func (repo *CachedBlueprintRepository) getRevision() (string, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.revision != "" {
+		return repo.revision, nil
+	}
+
+	info, err := readRepositoryInfo(repo.Dir)
+	if repo.Offline {
+		if err != nil {
+			return "", fmt.Errorf("repository %s is not in the offline cache, use it once without --offline first", repo.GetName())
+		}
+		util.Verbose("[cache] Using cached revision %s of repository %s\n", info.Revision, repo.GetName())
+		repo.revision = info.Revision
+		return repo.revision, nil
+	}
+
+	revisioned, ok := repo.Repo.(repository.RevisionedRepository)
+	if !ok {
+		return "", fmt.Errorf("repository %s cannot be cached", repo.GetName())
+	}
+	revision, err := revisioned.GetRevision()
+	if err != nil {
+		return "", err
+	}
+	if info == nil || info.Revision != revision {
+		repo.storeRevision(revision)
+	}
+	repo.revision = revision
+	return revision, nil
+}
+
+func (repo *CachedBlueprintRepository) storeRevision(revision string) {
+	content, err := yaml.Marshal(CachedRepositoryInfo{
+		Name:     repo.GetName(),
+		Provider: repo.GetProvider(),
+		Revision: revision,
+		Updated:  time.Now().Unix(),
+	})
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(repo.Dir, repositoryFileName), content)
+	}
+	if err != nil {
+		util.Verbose("[cache] Cannot cache revision of repository %s: %s\n", repo.GetName(), err)
+		return
+	}
+
+	// only the latest revision is kept
+	entries, _ := ioutil.ReadDir(repo.Dir)
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != revisionDirName(revision) {
+			util.Verbose("[cache] Removing outdated revision %s of repository %s\n", entry.Name(), repo.GetName())
+			os.RemoveAll(filepath.Join(repo.Dir, entry.Name()))
+		}
+	}
+}
+
+func (repo *CachedBlueprintRepository) revisionDir(revision string) string {
+	return filepath.Join(repo.Dir, revisionDirName(revision))
+}
+
+func revisionDirName(revision string) string {
+	name := strings.Trim(regExUnsafeChars.ReplaceAllString(revision, "_"), "_")
+	if name == "" || len(name) > 64 {
+		return fmt.Sprintf("%x", md5.Sum([]byte(revision)))
+	}
+	return name
+}
+
+func readRepositoryInfo(dir string) (*CachedRepositoryInfo, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, repositoryFileName))
+	if err != nil {
+		return nil, err
+	}
+	info := CachedRepositoryInfo{}
+	if err := yaml.Unmarshal(content, &info); err != nil {
+		return nil, err
+	}
+	if info.Revision == "" {
+		return nil, fmt.Errorf("cache file %s has no revision", filepath.Join(dir, repositoryFileName))
+	}
+	info.Dir = dir
+	return &info, nil
+}
+
+func readListing(listingPath string) (map[string]*models.BlueprintRemote, []string, error) {
+	content, err := ioutil.ReadFile(listingPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	listing := cachedListing{}
+	if err := yaml.Unmarshal(content, &listing); err != nil {
+		return nil, nil, err
+	}
+	blueprints := make(map[string]*models.BlueprintRemote)
+	for _, cached := range listing.Blueprints {
+		blueprint := models.NewBlueprintRemote(cached.Name, cached.Path)
+		blueprint.DefinitionFile = cached.DefinitionFile.toRemoteFile()
+		for _, file := range cached.Files {
+			blueprint.AddFile(file.toRemoteFile())
+		}
+		blueprints[cached.Path] = blueprint
+	}
+	return blueprints, listing.BlueprintDirs, nil
+}
+
+func writeListing(listingPath string, blueprints map[string]*models.BlueprintRemote, blueprintDirs []string) error {
+	listing := cachedListing{BlueprintDirs: blueprintDirs}
+	for _, blueprint := range blueprints {
+		cached := cachedBlueprint{
+			Name:           blueprint.Name,
+			Path:           blueprint.Path,
+			DefinitionFile: newCachedFile(blueprint.DefinitionFile),
+		}
+		for _, file := range blueprint.Files {
+			cached.Files = append(cached.Files, newCachedFile(file))
+		}
+		listing.Blueprints = append(listing.Blueprints, cached)
+	}
+	sort.Slice(listing.Blueprints, func(i, j int) bool { return listing.Blueprints[i].Path < listing.Blueprints[j].Path })
+
+	content, err := yaml.Marshal(listing)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(listingPath, content)
+}
+
+func newCachedFile(file models.RemoteFile) cachedFile {
+	cached := cachedFile{Filename: file.Filename, Path: file.Path}
+	if file.Url != nil {
+		cached.Url = file.Url.String()
+	}
+	return cached
+}
+
+func (file cachedFile) toRemoteFile() models.RemoteFile {
+	remoteFile := models.RemoteFile{Filename: file.Filename, Path: file.Path}
+	if file.Url != "" {
+		remoteFile.Url, _ = url.Parse(file.Url)
+	}
+	return remoteFile
+}
+
+// writeFileAtomic writes a cache file through a temporary file, so that concurrent readers never see a partial file
+func writeFileAtomic(filePath string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filePath), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(content)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), filePath)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+	}
+	return err
+}
+
+// ListCachedRepositories returns the repositories with content in the cache directory, sorted by name
+func ListCachedRepositories(cacheDir string) ([]CachedRepositoryInfo, error) {
+	entries, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CachedRepositoryInfo{}, nil
+		}
+		return nil, err
+	}
+	repositories := make([]CachedRepositoryInfo, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := readRepositoryInfo(filepath.Join(cacheDir, entry.Name()))
+		if err != nil {
+			util.Verbose("[cache] Ignoring invalid cache directory %s: %s\n", entry.Name(), err)
+			continue
+		}
+		filepath.Walk(filepath.Join(info.Dir, revisionDirName(info.Revision), filesDirName), func(path string, fileInfo os.FileInfo, err error) error {
+			if err == nil && !fileInfo.IsDir() {
+				info.Files++
+				info.Size += fileInfo.Size()
+			}
+			return nil
+		})
+		repositories = append(repositories, *info)
+	}
+	sort.Slice(repositories, func(i, j int) bool { return repositories[i].Name < repositories[j].Name })
+	return repositories, nil
+}
+
+// ClearCache removes the cached content of the named repositories, or of all repositories when no name is given,
+// and returns the names of the repositories removed
+func ClearCache(cacheDir string, names ...string) ([]string, error) {
+	repositories, err := ListCachedRepositories(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]string, 0)
+	for _, cached := range repositories {
+		matches := len(names) == 0
+		for _, name := range names {
+			matches = matches || strings.EqualFold(cached.Name, name)
+		}
+		if !matches {
+			continue
+		}
+		if err := os.RemoveAll(cached.Dir); err != nil {
+			return removed, err
+		}
+		removed = append(removed, cached.Name)
+	}
+	if len(names) == 0 {
+		// also removes invalid and partially written entries
+		if err := os.RemoveAll(cacheDir); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xebialabs/blueprint-cli/pkg/models"
+)
+
+type fakeRepository struct {
+	revision      string
+	files         map[string]string
+	listCalls     int
+	fileCalls     int
+	revisionCalls int
+}
+
+func (repo *fakeRepository) Initialize() error   { return nil }
+func (repo *fakeRepository) GetName() string     { return "My Repo" }
+func (repo *fakeRepository) GetProvider() string { return models.ProviderGitHub }
+func (repo *fakeRepository) GetInfo() string     { return "Provider: github\n  Name: My Repo" }
+
+func (repo *fakeRepository) GetRevision() (string, error) {
+	repo.revisionCalls++
+	if repo.revision == "" {
+		return "", fmt.Errorf("network is unreachable")
+	}
+	return repo.revision, nil
+}
+
+func (repo *fakeRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
+	repo.listCalls++
+	fileUrl, _ := url.Parse("https://example.com/aws/app/blueprint.yaml")
+	blueprint := models.NewBlueprintRemote("aws/app", "aws/app")
+	blueprint.DefinitionFile = models.RemoteFile{Filename: "blueprint.yaml", Path: "aws/app/blueprint.yaml", Url: fileUrl}
+	blueprint.AddFile(models.RemoteFile{Filename: "app.yaml.tmpl", Path: "aws/app/app.yaml.tmpl"})
+	return map[string]*models.BlueprintRemote{"aws/app": blueprint}, []string{"aws/app"}, nil
+}
+
+func (repo *fakeRepository) GetFileContents(filePath string) (*[]byte, error) {
+	repo.fileCalls++
+	content, ok := repo.files[filePath]
+	if !ok {
+		return nil, fmt.Errorf("404 file %s not found", filePath)
+	}
+	contentBytes := []byte(content)
+	return &contentBytes, nil
+}
+
+func TestCachedBlueprintRepository(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "blueprintcache")
+	require.Nil(t, err)
+	defer os.RemoveAll(cacheDir)
+	remote := &fakeRepository{revision: "abc123", files: map[string]string{"aws/app/blueprint.yaml": "apiVersion: xl/v2"}}
+
+	t.Run("should fetch and cache the blueprint list and files", func(t *testing.T) {
+		repo := NewCachedBlueprintRepository(remote, cacheDir, false)
+		require.Nil(t, repo.Initialize())
+		blueprints, dirs, err := repo.ListBlueprintsFromRepo()
+		require.Nil(t, err)
+		assert.Equal(t, []string{"aws/app"}, dirs)
+		assert.Equal(t, "aws/app/app.yaml.tmpl", blueprints["aws/app"].Files[0].Path)
+
+		content, err := repo.GetFileContents("aws/app/blueprint.yaml")
+		require.Nil(t, err)
+		assert.Equal(t, "apiVersion: xl/v2", string(*content))
+
+		_, err = repo.GetFileContents("aws/app/missing.yaml")
+		require.NotNil(t, err)
+		assert.Equal(t, 1, remote.listCalls)
+		assert.Equal(t, 2, remote.fileCalls)
+	})
+
+	t.Run("should serve the cached content while the revision is unchanged", func(t *testing.T) {
+		repo := NewCachedBlueprintRepository(remote, cacheDir, false)
+		blueprints, _, err := repo.ListBlueprintsFromRepo()
+		require.Nil(t, err)
+		assert.Equal(t, "https://example.com/aws/app/blueprint.yaml", blueprints["aws/app"].DefinitionFile.Url.String())
+		content, err := repo.GetFileContents("aws/app/blueprint.yaml")
+		require.Nil(t, err)
+		assert.Equal(t, "apiVersion: xl/v2", string(*content))
+		assert.Equal(t, 1, remote.listCalls)
+		assert.Equal(t, 2, remote.fileCalls)
+	})
+
+	t.Run("should only use the cache in offline mode", func(t *testing.T) {
+		revisionCalls := remote.revisionCalls
+		repo := NewCachedBlueprintRepository(remote, cacheDir, true)
+		require.Nil(t, repo.Initialize())
+		_, dirs, err := repo.ListBlueprintsFromRepo()
+		require.Nil(t, err)
+		assert.Equal(t, []string{"aws/app"}, dirs)
+		_, err = repo.GetFileContents("aws/app/blueprint.yaml")
+		require.Nil(t, err)
+
+		_, err = repo.GetFileContents("aws/app/app.yaml.tmpl")
+		require.NotNil(t, err)
+		assert.Equal(t, "file aws/app/app.yaml.tmpl of repository My Repo is not in the offline cache", err.Error())
+		assert.Equal(t, revisionCalls, remote.revisionCalls)
+		assert.Equal(t, 2, remote.fileCalls)
+	})
+
+	t.Run("should refresh the cache when the revision changes", func(t *testing.T) {
+		remote.revision = "def456"
+		repo := NewCachedBlueprintRepository(remote, cacheDir, false)
+		_, _, err := repo.ListBlueprintsFromRepo()
+		require.Nil(t, err)
+		_, err = repo.GetFileContents("aws/app/blueprint.yaml")
+		require.Nil(t, err)
+		assert.Equal(t, 2, remote.listCalls)
+		assert.Equal(t, 3, remote.fileCalls)
+
+		repositories, err := ListCachedRepositories(cacheDir)
+		require.Nil(t, err)
+		require.Len(t, repositories, 1)
+		assert.Equal(t, "My Repo", repositories[0].Name)
+		assert.Equal(t, "def456", repositories[0].Revision)
+		assert.Equal(t, 1, repositories[0].Files)
+		_, err = os.Stat(repo.revisionDir("abc123"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should fail offline when the repository is not cached", func(t *testing.T) {
+		removed, err := ClearCache(cacheDir, "my repo")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"My Repo"}, removed)
+
+		repo := NewCachedBlueprintRepository(remote, cacheDir, true)
+		_, _, err = repo.ListBlueprintsFromRepo()
+		require.NotNil(t, err)
+		assert.Equal(t, "repository My Repo is not in the offline cache, use it once without --offline first", err.Error())
+	})
+}
+
+func TestGetRepositoryCacheKey(t *testing.T) {
+	assert.Regexp(t, `^My_Repo-[0-9a-f]{8}$`, GetRepositoryCacheKey(&fakeRepository{}))
+	assert.Equal(t, "abc123", revisionDirName("abc123"))
+	assert.Equal(t, "W_5d8-1", revisionDirName(`W/"5d8-1"`))
+}
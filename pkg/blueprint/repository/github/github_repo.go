@@ -85,16 +85,24 @@ func (repo *GitHubBlueprintRepository) GetInfo() string {
 	)
 }
 
+// GetRevision returns the SHA of the latest commit of the branch
+func (repo *GitHubBlueprintRepository) GetRevision() (string, error) {
+	branch, _, err := repo.Client.Repositories.GetBranch(repo.Client.Context, repo.Owner, repo.RepoName, repo.Branch)
+	if err != nil {
+		return "", err
+	}
+	return branch.GetCommit().GetSHA(), nil
+}
+
 func (repo *GitHubBlueprintRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
 	blueprints := make(map[string]*models.BlueprintRemote)
 	var blueprintDirs []string
 
 	// Get latest SHA of the requested branch
-	branch, _, err := repo.Client.Repositories.GetBranch(repo.Client.Context, repo.Owner, repo.RepoName, repo.Branch)
+	sha, err := repo.GetRevision()
 	if err != nil {
 		return nil, nil, err
 	}
-	sha := branch.GetCommit().GetSHA()
 
 	// Get GIT tree
 	tree, _, err := repo.Client.Git.GetTree(repo.Client.Context, repo.Owner, repo.RepoName, sha, true)
@@ -98,16 +98,24 @@ func (repo *GitLabBlueprintRepository) GetInfo() string {
 	)
 }
 
+// GetRevision returns the SHA of the latest commit of the branch
+func (repo *GitLabBlueprintRepository) GetRevision() (string, error) {
+	branch, _, err := repo.Client.Branches.GetBranch(fmt.Sprintf("%s/%s", repo.Owner, repo.RepoName), repo.Branch, nil)
+	if err != nil {
+		return "", err
+	}
+	return branch.Commit.ID, nil
+}
+
 func (repo *GitLabBlueprintRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
 	blueprints := make(map[string]*models.BlueprintRemote)
 	var blueprintDirs []string
 
 	// Get latest SHA of the requested branch
-	branch, _, err := repo.Client.Branches.GetBranch(fmt.Sprintf("%s/%s", repo.Owner, repo.RepoName), repo.Branch, nil)
+	sha, err := repo.GetRevision()
 	if err != nil {
 		return nil, nil, err
 	}
-	sha := branch.Commit.ID
 
 	lto := &gitlab.ListTreeOptions{
 		ListOptions: gitlab.ListOptions{
@@ -175,11 +183,10 @@ func (repo *GitLabBlueprintRepository) ListBlueprintsFromRepo() (map[string]*mod
 
 func (repo *GitLabBlueprintRepository) GetFileContents(filePath string) (*[]byte, error) {
 	// Get latest SHA of the requested branch
-	branch, _, err := repo.Client.Branches.GetBranch(fmt.Sprintf("%s/%s", repo.Owner, repo.RepoName), repo.Branch, nil)
+	sha, err := repo.GetRevision()
 	if err != nil {
 		return nil, err
 	}
-	sha := branch.Commit.ID
 
 	rfo := &gitlab.GetRawFileOptions{
 		Ref: gitlab.String(sha),
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"crypto/md5"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -80,6 +81,30 @@ func (repo *HttpBlueprintRepository) GetInfo() string {
 	)
 }
 
+// GetRevision returns the ETag of the repository index file, or its last modification time or content hash when the
+// server does not send an ETag
+func (repo *HttpBlueprintRepository) GetRevision() (string, error) {
+	response, err := repo.getResponseFromUrl(RepoIndexFileName)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 400 {
+		return "", fmt.Errorf("%d unable to read remote http file [%s]", response.StatusCode, RepoIndexFileName)
+	}
+	if etag := response.Header.Get("ETag"); etag != "" {
+		return etag, nil
+	}
+	if lastModified := response.Header.Get("Last-Modified"); lastModified != "" {
+		return lastModified, nil
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", md5.Sum(body)), nil
+}
+
 func (repo *HttpBlueprintRepository) ListBlueprintsFromRepo() (map[string]*models.BlueprintRemote, []string, error) {
 	blueprints := make(map[string]*models.BlueprintRemote)
 	var blueprintDirs []string
@@ -51,7 +51,11 @@ func NewLocalBlueprintRepository(confMap map[string]string) (*LocalBlueprintRepo
 	case mode.IsRegular():
 		return nil, fmt.Errorf("got file path [%s] instead of a local directory path", repoDir)
 	}
-	repo.Path = repoDir
+	// keep an absolute path so that the repository can still be read after the working directory changes
+	repo.Path, err = filepath.Abs(repoDir)
+	if err != nil {
+		return nil, err
+	}
 
 	// parse ignored dirs & files
 	if util.MapContainsKeyWithVal(confMap, "ignored-dirs") {
@@ -98,6 +102,10 @@ func (repo *LocalBlueprintRepository) GetInfo() string {
 }
 
 func (repo *LocalBlueprintRepository) traversePath(path string, info os.FileInfo, err error) error {
+	// the path cannot be read, for instance the repository directory was removed
+	if err != nil {
+		return err
+	}
 	if info.IsDir() {
 		// skip ignored directories
 		dir := filepath.Base(path)
@@ -132,7 +140,9 @@ func (repo *LocalBlueprintRepository) ListBlueprintsFromRepo() (map[string]*mode
 	blueprints := make(map[string]*models.BlueprintRemote)
 	var blueprintDirs []string
 
-	// walk root directory
+	// walk root directory, starting from a clean state when the repository is listed again
+	repo.LocalFiles = []string{}
+	repo.BlueprintDirs = []string{}
 	err := filepath.Walk(repo.Path, repo.traversePath)
 	if err != nil {
 		return nil, nil, err
@@ -144,7 +154,8 @@ func (repo *LocalBlueprintRepository) ListBlueprintsFromRepo() (map[string]*mode
 			// if local file is within any valid blueprint directory
 			filename := filepath.Base(file)
 			currentPath, _ := filepath.Rel(repo.Path, blueprintDir)
-			filePath := filepath.Join(currentPath, filename)
+			// keep the path of files in nested directories relative to the repository root
+			filePath, _ := filepath.Rel(repo.Path, file)
 			if repository.CheckIfBlueprintDefinitionFile(filename) {
 				blueprints[currentPath].DefinitionFile = repository.GenerateBlueprintFileDefinition(
 					blueprints,
@@ -110,10 +110,10 @@ func TestListBlueprintsFromRepo(t *testing.T) {
 		require.Nil(t, err)
 		require.NotNil(t, blueprints)
 		assert.NotEmpty(t, blueprints)
-		assert.Len(t, blueprints, 12)
+		assert.Len(t, blueprints, 21)
 		require.NotNil(t, blueprintDirs)
 		assert.NotEmpty(t, blueprintDirs)
-		assert.Len(t, blueprintDirs, 12)
+		assert.Len(t, blueprintDirs, 21)
 
 		answerInputBlueprint := blueprints["answer-input"]
 		assert.Equal(t, "answer-input", answerInputBlueprint.Path)
@@ -124,6 +124,31 @@ func TestListBlueprintsFromRepo(t *testing.T) {
 		assert.Len(t, validNoPromptBlueprint.Files, 5)
 	})
 
+	t.Run("should keep nested file paths relative to the repository", func(t *testing.T) {
+		repo, err := NewLocalBlueprintRepository(map[string]string{
+			"name": "test",
+			"type": repoType,
+			"path": blueprintDir,
+		})
+		require.Nil(t, err)
+		blueprints, _, err := repo.ListBlueprintsFromRepo()
+		require.Nil(t, err)
+		blueprints, _, err = repo.ListBlueprintsFromRepo()
+		require.Nil(t, err)
+
+		var filePaths []string
+		for _, file := range blueprints["defaults-as-values"].Files {
+			filePaths = append(filePaths, file.Path)
+		}
+		assert.ElementsMatch(t, []string{
+			filepath.Join("defaults-as-values", "__test__", "test-case-1.yaml"),
+			filepath.Join("defaults-as-values", "cert"),
+			filepath.Join("defaults-as-values", "xld-environment.yml.tmpl"),
+			filepath.Join("defaults-as-values", "xld-infrastructure.yml.tmpl"),
+			filepath.Join("defaults-as-values", "xlr-pipeline.yml"),
+		}, filePaths)
+	})
+
 	t.Run("should list empty blueprints list from local dir", func(t *testing.T) {
 		repo, err := NewLocalBlueprintRepository(map[string]string{
 			"name": "test",
@@ -0,0 +1,308 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Line diff operations
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+type DiffOp int
+
+// diffMaxSearchRounds limits the search for the edit script of a changed region, larger changes are given as the
+// removal of the region followed by its new lines, which keeps big rewrites of a file fast
+const diffMaxSearchRounds = 1000
+
+// DiffLine is a single line of a line based diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// SplitLines splits text into lines, a trailing newline does not produce an empty last line
+func SplitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// DiffLines returns the shortest edit script turning lines a into lines b (Myers' algorithm, in linear space), except
+// for changed regions too large to search which are replaced as a whole, see diffMaxSearchRounds
+func DiffLines(a, b []string) []DiffLine {
+	return appendDiffLines(make([]DiffLine, 0, len(a)+len(b)), a, b)
+}
+
+// appendDiffLines appends the edit script of a into b to lines, the differing part is split on the middle snake of
+// its shortest edit script so that no trace of the search has to be kept
+func appendDiffLines(lines []DiffLine, a, b []string) []DiffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for _, line := range a[:prefix] {
+		lines = append(lines, DiffLine{DiffEqual, line})
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			lines = append(lines, DiffLine{DiffInsert, line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			lines = append(lines, DiffLine{DiffDelete, line})
+		}
+	default:
+		x, y, u, v, found := middleSnake(a, b)
+		if !found {
+			for _, line := range a {
+				lines = append(lines, DiffLine{DiffDelete, line})
+			}
+			for _, line := range b {
+				lines = append(lines, DiffLine{DiffInsert, line})
+			}
+			break
+		}
+		// both halves have a shorter edit script, since the first and last lines differ it has at least 2 edits
+		lines = appendDiffLines(lines, a[:x], b[:y])
+		for _, line := range a[x:u] {
+			lines = append(lines, DiffLine{DiffEqual, line})
+		}
+		lines = appendDiffLines(lines, a[u:], b[v:])
+	}
+
+	for _, line := range common {
+		lines = append(lines, DiffLine{DiffEqual, line})
+	}
+	return lines
+}
+
+// middleSnake searches the shortest edit script of a into b from both ends at once, and returns the start and end of
+// the snake where both searches meet, or false when they do not meet within diffMaxSearchRounds. Positions on the
+// backward search are counted from the end of a and b.
+func middleSnake(a, b []string) (x, y, u, v int, found bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	if max > diffMaxSearchRounds {
+		max = diffMaxSearchRounds
+	}
+	offset := max + 1
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			// diagonal k is diagonal delta-k of the backward search, which has done d-1 rounds
+			if odd && delta-k >= -(d-1) && delta-k <= d-1 && u+backward[offset+delta-k] >= n {
+				return x, y, u, v, true
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[n-1-u] == b[m-1-v] {
+				u++
+				v++
+			}
+			backward[offset+k] = u
+			if !odd && delta-k >= -d && delta-k <= d && u+forward[offset+delta-k] >= n {
+				return n - u, m - v, n - x, m - y, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// UnifiedDiff returns the differences between two texts in unified diff format, or an empty string when they are equal
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	lines := DiffLines(SplitLines(from), SplitLines(to))
+
+	// line numbers in both texts before each diff line
+	fromLines := make([]int, len(lines)+1)
+	toLines := make([]int, len(lines)+1)
+	var changes []int
+	for i, line := range lines {
+		fromLines[i+1], toLines[i+1] = fromLines[i], toLines[i]
+		if line.Op != DiffInsert {
+			fromLines[i+1]++
+		}
+		if line.Op != DiffDelete {
+			toLines[i+1]++
+		}
+		if line.Op != DiffEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(changes); {
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[i] + context + 1
+		// merge the following changes whose context overlaps with this hunk
+		for i++; i < len(changes) && changes[i]-context <= end; i++ {
+			end = changes[i] + context + 1
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		fromCount := fromLines[end] - fromLines[start]
+		toCount := toLines[end] - toLines[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(fromLines[start], fromCount), hunkRange(toLines[start], toCount))
+		for _, line := range lines[start:end] {
+			switch line.Op {
+			case DiffEqual:
+				sb.WriteString(" ")
+			case DiffDelete:
+				sb.WriteString("-")
+			case DiffInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(line.Text)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Merge3 merges the changes made from base to ours and from base to theirs line by line (diff3 style). Changes that
+// overlap and differ are kept both, between conflict markers using the given labels, and reported as a conflict.
+func Merge3(base, ours, theirs, oursLabel, theirsLabel string) (string, bool) {
+	baseLines, ourLines, theirLines := SplitLines(base), SplitLines(ours), SplitLines(theirs)
+	ourMatches := matchLines(baseLines, ourLines)
+	theirMatches := matchLines(baseLines, theirLines)
+
+	var merged []string
+	conflict := false
+	i, j, k := 0, 0, 0
+	for {
+		// copy the lines unchanged in both versions
+		for i < len(baseLines) && ourMatches[i] == j && theirMatches[i] == k {
+			merged = append(merged, baseLines[i])
+			i, j, k = i+1, j+1, k+1
+		}
+
+		// find the next base line kept in both versions, everything until there is a changed chunk
+		nextI, nextJ, nextK := i, len(ourLines), len(theirLines)
+		for ; nextI < len(baseLines); nextI++ {
+			if ourMatches[nextI] != -1 && theirMatches[nextI] != -1 {
+				nextJ, nextK = ourMatches[nextI], theirMatches[nextI]
+				break
+			}
+		}
+
+		baseChunk, ourChunk, theirChunk := baseLines[i:nextI], ourLines[j:nextJ], theirLines[k:nextK]
+		switch {
+		case equalLines(ourChunk, baseChunk):
+			merged = append(merged, theirChunk...)
+		case equalLines(theirChunk, baseChunk), equalLines(ourChunk, theirChunk):
+			merged = append(merged, ourChunk...)
+		default:
+			conflict = true
+			merged = append(merged, "<<<<<<< "+oursLabel)
+			merged = append(merged, ourChunk...)
+			merged = append(merged, "=======")
+			merged = append(merged, theirChunk...)
+			merged = append(merged, ">>>>>>> "+theirsLabel)
+		}
+
+		i, j, k = nextI, nextJ, nextK
+		if i >= len(baseLines) && j >= len(ourLines) && k >= len(theirLines) {
+			break
+		}
+	}
+
+	if len(merged) == 0 {
+		return "", conflict
+	}
+	// the trailing newline is handled like any other change
+	trailingNewline := strings.HasSuffix(theirs, "\n")
+	if strings.HasSuffix(ours, "\n") != strings.HasSuffix(base, "\n") {
+		trailingNewline = strings.HasSuffix(ours, "\n")
+	}
+	result := strings.Join(merged, "\n")
+	if trailingNewline {
+		result += "\n"
+	}
+	return result, conflict
+}
+
+// matchLines returns for every line of a the index of the same line in b, or -1 when it is not kept in b
+func matchLines(a, b []string) []int {
+	matches := make([]int, len(a))
+	x, y := 0, 0
+	for _, line := range DiffLines(a, b) {
+		switch line.Op {
+		case DiffEqual:
+			matches[x] = y
+			x, y = x+1, y+1
+		case DiffDelete:
+			matches[x] = -1
+			x++
+		case DiffInsert:
+			y++
+		}
+	}
+	return matches
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
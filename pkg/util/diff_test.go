@@ -0,0 +1,117 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	t.Run("should return equal lines for identical input", func(t *testing.T) {
+		assert.Equal(t, []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}}, DiffLines([]string{"a", "b"}, []string{"a", "b"}))
+	})
+
+	t.Run("should return the shortest edit script", func(t *testing.T) {
+		assert.Equal(t, []DiffLine{
+			{DiffEqual, "a"},
+			{DiffDelete, "b"},
+			{DiffInsert, "x"},
+			{DiffEqual, "c"},
+			{DiffInsert, "d"},
+		}, DiffLines([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"}))
+	})
+
+	t.Run("should handle empty input", func(t *testing.T) {
+		assert.Equal(t, []DiffLine{{DiffInsert, "a"}}, DiffLines([]string{}, []string{"a"}))
+		assert.Equal(t, []DiffLine{{DiffDelete, "a"}}, DiffLines([]string{"a"}, []string{}))
+		assert.Empty(t, DiffLines([]string{}, []string{}))
+	})
+
+	t.Run("should diff large files that differ everywhere", func(t *testing.T) {
+		a, b := make([]string, 20000), make([]string, 20000)
+		for i := range a {
+			a[i], b[i] = fmt.Sprintf("a%d", i), fmt.Sprintf("b%d", i)
+		}
+		lines := DiffLines(a, b)
+		assert.Len(t, lines, 40000)
+		assert.Equal(t, DiffLine{DiffDelete, "a0"}, lines[0])
+		assert.Equal(t, DiffLine{DiffInsert, "b19999"}, lines[39999])
+	})
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("should return empty string for equal texts", func(t *testing.T) {
+		assert.Equal(t, "", UnifiedDiff("a", "b", "same\ntext\n", "same\ntext\n", 3))
+	})
+
+	t.Run("should return hunks with context", func(t *testing.T) {
+		from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+		to := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n"
+		assert.Equal(t, `--- expected
++++ actual
+@@ -2,3 +2,3 @@
+ 2
+-3
++three
+ 4
+@@ -10 +10,2 @@
+ 10
++11
+`, UnifiedDiff("expected", "actual", from, to, 1))
+	})
+
+	t.Run("should merge overlapping hunks", func(t *testing.T) {
+		assert.Equal(t, `--- a
++++ b
+@@ -1,3 +1,3 @@
+-1
++one
+ 2
+-3
++three
+`, UnifiedDiff("a", "b", "1\n2\n3", "one\n2\nthree", 1))
+	})
+
+	t.Run("should diff against empty text", func(t *testing.T) {
+		assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n", UnifiedDiff("a", "b", "", "new\n", 3))
+	})
+}
+
+func TestMerge3(t *testing.T) {
+	base := "1\n2\n3\n4\n5\n"
+
+	t.Run("should take changes from both sides", func(t *testing.T) {
+		merged, conflict := Merge3(base, "one\n2\n3\n4\n5\n", "1\n2\n3\n4\nfive\nsix\n", "local", "blueprint")
+		assert.False(t, conflict)
+		assert.Equal(t, "one\n2\n3\n4\nfive\nsix\n", merged)
+	})
+
+	t.Run("should accept identical changes", func(t *testing.T) {
+		merged, conflict := Merge3(base, "1\ntwo\n3\n4\n5\n", "1\ntwo\n3\n4\n5\n", "local", "blueprint")
+		assert.False(t, conflict)
+		assert.Equal(t, "1\ntwo\n3\n4\n5\n", merged)
+	})
+
+	t.Run("should keep deletions", func(t *testing.T) {
+		merged, conflict := Merge3(base, "1\n2\n4\n5\n", "1\n2\n3\n4\n5\n6\n", "local", "blueprint")
+		assert.False(t, conflict)
+		assert.Equal(t, "1\n2\n4\n5\n6\n", merged)
+	})
+
+	t.Run("should mark conflicting changes", func(t *testing.T) {
+		merged, conflict := Merge3(base, "1\nmine\n3\n4\n5\n", "1\ntheirs\n3\n4\n5\n", "local", "blueprint")
+		assert.True(t, conflict)
+		assert.Equal(t, "1\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> blueprint\n3\n4\n5\n", merged)
+	})
+
+	t.Run("should merge additions to an empty base", func(t *testing.T) {
+		merged, conflict := Merge3("", "a\n", "b\n", "local", "blueprint")
+		assert.True(t, conflict)
+		assert.Equal(t, "<<<<<<< local\na\n=======\nb\n>>>>>>> blueprint\n", merged)
+
+		merged, conflict = Merge3("", "", "", "local", "blueprint")
+		assert.False(t, conflict)
+		assert.Equal(t, "", merged)
+	})
+}